@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxBatch is how many mutations batchMutate sends in one request, to stay
+// well inside GitHub's limits on request complexity.
+const maxBatch = 50
+
+// mutationOp is one mutation in a batch. Input is sent as a variable of the
+// mutation's input type, e.g. CloseIssueInput for closeIssue.
+type mutationOp struct {
+	Mutation string
+	Input    map[string]interface{}
+}
+
+// batchMutate sends ops as aliased mutations in as few requests as
+// possible. GitHub runs the mutations of a request in order, so ops may
+// depend on earlier ones.
+func (c *Client) batchMutate(ctx context.Context, ops []mutationOp) error {
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > maxBatch {
+			n = maxBatch
+		}
+		var vars, fields []string
+		for i, op := range ops[:n] {
+			inputType := strings.ToUpper(op.Mutation[:1]) + op.Mutation[1:] + "Input"
+			vars = append(vars, fmt.Sprintf("$input%d: %s!", i, inputType))
+			fields = append(fields, fmt.Sprintf("m%d: %s(input: $input%d) { clientMutationId }", i, op.Mutation, i))
+		}
+		req := c.newRequest(fmt.Sprintf("mutation batch(%s) {\n%s\n}", strings.Join(vars, ", "), strings.Join(fields, "\n")))
+		for i, op := range ops[:n] {
+			req.Var(fmt.Sprintf("input%d", i), op.Input)
+		}
+
+		res := struct{}{}
+		if err := c.mutate(ctx, req, &res); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// AssignIssues assigns user to each of issues in one batch.
+func (c *Client) AssignIssues(ctx context.Context, user string, issues []Content) error {
+	return c.batchAssignees(ctx, "addAssigneesToAssignable", user, issues)
+}
+
+// UnassignIssues removes user from each of issues in one batch.
+func (c *Client) UnassignIssues(ctx context.Context, user string, issues []Content) error {
+	return c.batchAssignees(ctx, "removeAssigneesFromAssignable", user, issues)
+}
+
+func (c *Client) batchAssignees(ctx context.Context, mutation, user string, issues []Content) error {
+	userID, err := c.getUserID(ctx, user)
+	if err != nil {
+		return err
+	}
+	var ops []mutationOp
+	for _, issue := range issues {
+		ops = append(ops, mutationOp{mutation, map[string]interface{}{
+			"assignableId": issue.ID,
+			"assigneeIds":  []string{userID},
+		}})
+	}
+	return c.batchMutate(ctx, ops)
+}
+
+// CloseIssues closes each of issues in one batch.
+func (c *Client) CloseIssues(ctx context.Context, issues []Content) error {
+	return c.batchState(ctx, "closeIssue", issues)
+}
+
+// ReopenIssues reopens each of issues in one batch.
+func (c *Client) ReopenIssues(ctx context.Context, issues []Content) error {
+	return c.batchState(ctx, "reopenIssue", issues)
+}
+
+func (c *Client) batchState(ctx context.Context, mutation string, issues []Content) error {
+	var ops []mutationOp
+	for _, issue := range issues {
+		ops = append(ops, mutationOp{mutation, map[string]interface{}{"issueId": issue.ID}})
+	}
+	return c.batchMutate(ctx, ops)
+}
+
+// LabelIssues adds and removes labels on each of issues in one batch, and
+// returns the issues with their labels updated. Labels are looked up by
+// name in each issue's repository.
+func (c *Client) LabelIssues(ctx context.Context, issues []Content, add, remove []string) ([]Content, error) {
+	repoLabels := make(map[string][]Label)
+	var ops []mutationOp
+	var updated []Content
+	for _, issue := range issues {
+		repo := issue.Repository.NameWithOwner
+		labels, ok := repoLabels[repo]
+		if !ok {
+			var err error
+			if labels, err = c.GetLabels(ctx, repo); err != nil {
+				return nil, err
+			}
+			repoLabels[repo] = labels
+		}
+		toAdd, err := findLabels(labels, add, repo)
+		if err != nil {
+			return nil, err
+		}
+		toRemove, err := findLabels(labels, remove, repo)
+		if err != nil {
+			return nil, err
+		}
+		if len(toAdd) > 0 {
+			ops = append(ops, mutationOp{"addLabelsToLabelable", map[string]interface{}{
+				"labelableId": issue.ID,
+				"labelIds":    labelIDs(toAdd),
+			}})
+		}
+		if len(toRemove) > 0 {
+			ops = append(ops, mutationOp{"removeLabelsFromLabelable", map[string]interface{}{
+				"labelableId": issue.ID,
+				"labelIds":    labelIDs(toRemove),
+			}})
+		}
+		issue.Labels.Nodes = applyLabels(issue.Labels.Nodes, toAdd, toRemove)
+		updated = append(updated, issue)
+	}
+	if err := c.batchMutate(ctx, ops); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// SetMilestone puts each of issues in the milestone titled title, or takes
+// them out of their milestone if title is empty, in one batch. It returns
+// the issues with their milestone updated.
+func (c *Client) SetMilestone(ctx context.Context, issues []Content, title string) ([]Content, error) {
+	repoMilestones := make(map[string][]Milestone)
+	var ops []mutationOp
+	var updated []Content
+	for _, issue := range issues {
+		var milestone *Milestone
+		if title != "" {
+			repo := issue.Repository.NameWithOwner
+			milestones, ok := repoMilestones[repo]
+			if !ok {
+				var err error
+				if milestones, err = c.GetMilestones(ctx, repo); err != nil {
+					return nil, err
+				}
+				repoMilestones[repo] = milestones
+			}
+			for i := range milestones {
+				if strings.EqualFold(milestones[i].Title, title) {
+					milestone = &milestones[i]
+				}
+			}
+			if milestone == nil {
+				titles := make([]string, len(milestones))
+				for i, m := range milestones {
+					titles[i] = m.Title
+				}
+				return nil, fmt.Errorf("no open milestone %q in %s%s", title, repo, suggest(title, titles))
+			}
+		}
+		var milestoneID interface{}
+		if milestone != nil {
+			milestoneID = milestone.ID
+		}
+		if strings.Contains(issue.URL, "pull") {
+			ops = append(ops, mutationOp{"updatePullRequest", map[string]interface{}{
+				"pullRequestId": issue.ID,
+				"milestoneId":   milestoneID,
+			}})
+		} else {
+			ops = append(ops, mutationOp{"updateIssue", map[string]interface{}{
+				"id":          issue.ID,
+				"milestoneId": milestoneID,
+			}})
+		}
+		issue.Milestone = nil
+		if milestone != nil {
+			issue.Milestone = &Milestone{Title: milestone.Title}
+		}
+		updated = append(updated, issue)
+	}
+	if err := c.batchMutate(ctx, ops); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// MoveCards moves cards, which may include notes, to the named column in
+// one batch.
+func (c *Client) MoveCards(ctx context.Context, ref ProjectRef, cards []Node, colName string) error {
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	project := proj.Owner.Project
+	var colID string
+	for _, col := range project.Columns.Nodes {
+		if col.ID != "" && matchColumn(col.Name, colName) {
+			colID = col.ID
+		}
+	}
+	clearStatus := ref.V2 && matchColumn(noStatusColumn, colName)
+	if colID == "" && !clearStatus {
+		return fmt.Errorf("couldn't move cards: no column %q", colName)
+	}
+	var ops []mutationOp
+	for _, card := range cards {
+		switch {
+		case !ref.V2:
+			ops = append(ops, mutationOp{"moveProjectCard", map[string]interface{}{
+				"cardId":   card.ID,
+				"columnId": colID,
+			}})
+		case clearStatus:
+			ops = append(ops, mutationOp{"clearProjectV2ItemFieldValue", map[string]interface{}{
+				"projectId": project.ID,
+				"itemId":    card.ID,
+				"fieldId":   project.StatusFieldID,
+			}})
+		default:
+			ops = append(ops, mutationOp{"updateProjectV2ItemFieldValue", map[string]interface{}{
+				"projectId": project.ID,
+				"itemId":    card.ID,
+				"fieldId":   project.StatusFieldID,
+				"value":     map[string]interface{}{"singleSelectOptionId": colID},
+			}})
+		}
+	}
+	return c.batchMutate(ctx, ops)
+}
+
+// ArchiveCards archives cards, or restores them from the archive if
+// archived is false, in one batch.
+func (c *Client) ArchiveCards(ctx context.Context, ref ProjectRef, cards []Node, archived bool) error {
+	var ops []mutationOp
+	if !ref.V2 {
+		for _, card := range cards {
+			ops = append(ops, mutationOp{"updateProjectCard", map[string]interface{}{
+				"projectCardId": card.ID,
+				"isArchived":    archived,
+			}})
+		}
+		return c.batchMutate(ctx, ops)
+	}
+	projectID, err := c.projectV2ID(ctx, ref)
+	if err != nil {
+		return err
+	}
+	mutation := "archiveProjectV2Item"
+	if !archived {
+		mutation = "unarchiveProjectV2Item"
+	}
+	for _, card := range cards {
+		ops = append(ops, mutationOp{mutation, map[string]interface{}{
+			"projectId": projectID,
+			"itemId":    card.ID,
+		}})
+	}
+	return c.batchMutate(ctx, ops)
+}
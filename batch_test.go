@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// recordRequests starts a server that answers every request with an empty
+// result and appends the requests it gets to reqs.
+func recordRequests(t *testing.T, reqs *[]graphQLRequest) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		*reqs = append(*reqs, req)
+		fmt.Fprint(w, `{"data": {}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient("token", WithEndpoint(srv.URL))
+}
+
+func TestBatchMutate(t *testing.T) {
+	tests := []struct {
+		name      string
+		ops       []mutationOp
+		wantQuery []string
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name: "aliases",
+			ops: []mutationOp{
+				{"closeIssue", map[string]interface{}{"issueId": "I_1"}},
+				{"addLabelsToLabelable", map[string]interface{}{"labelableId": "I_1", "labelIds": []string{"L_1"}}},
+			},
+			wantQuery: []string{
+				"mutation batch($input0: CloseIssueInput!, $input1: AddLabelsToLabelableInput!) {\n" +
+					"m0: closeIssue(input: $input0) { clientMutationId }\n" +
+					"m1: addLabelsToLabelable(input: $input1) { clientMutationId }\n}",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqs []graphQLRequest
+			c := recordRequests(t, &reqs)
+			if err := c.batchMutate(context.Background(), tt.ops); err != nil {
+				t.Fatal(err)
+			}
+			if len(reqs) != len(tt.wantQuery) {
+				t.Fatalf("sent %d requests, want %d", len(reqs), len(tt.wantQuery))
+			}
+			for i, req := range reqs {
+				if req.Query != tt.wantQuery[i] {
+					t.Errorf("query = %q, want %q", req.Query, tt.wantQuery[i])
+				}
+			}
+			for i, op := range tt.ops {
+				got := reqs[0].Variables[fmt.Sprintf("input%d", i)].(map[string]interface{})
+				for k := range op.Input {
+					if _, ok := got[k]; !ok {
+						t.Errorf("input%d is missing %s: %v", i, k, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBatchMutateSplits(t *testing.T) {
+	var reqs []graphQLRequest
+	c := recordRequests(t, &reqs)
+	var ops []mutationOp
+	for i := 0; i < maxBatch+3; i++ {
+		ops = append(ops, mutationOp{"reopenIssue", map[string]interface{}{"issueId": fmt.Sprintf("I_%d", i)}})
+	}
+	if err := c.batchMutate(context.Background(), ops); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(reqs))
+	}
+	if n := strings.Count(reqs[1].Query, "reopenIssue("); n != 3 {
+		t.Errorf("second request has %d mutations, want 3", n)
+	}
+	// aliases restart in each request, and inputs keep their order
+	last := reqs[1].Variables["input2"].(map[string]interface{})
+	if id := last["issueId"]; id != fmt.Sprintf("I_%d", maxBatch+2) {
+		t.Errorf("last input = %v, want I_%d", id, maxBatch+2)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// The kanban layout shows each column as its own list, side by side. The
+// lists reference the rows of the flat table, so the selection and marks
+// mean the same in both layouts.
+
+// minColumnWidth is the narrowest a column is drawn on the board. When not
+// all of them fit, the board scrolls sideways to show the selected one.
+const minColumnWidth = 30
+
+// layout returns the primitive showing the cards in the current layout.
+func (t *tui) layout() tview.Primitive {
+	if t.kanban {
+		return t.board
+	}
+	return t.table
+}
+
+// view returns the primitive to focus in the current layout.
+func (t *tui) view() tview.Primitive {
+	if !t.kanban {
+		return t.table
+	}
+	if t.boardCol < len(t.boardCols) {
+		return t.boardCols[t.boardCol]
+	}
+	return t.board
+}
+
+// boardFocused reports whether the cards have the focus, rather than the
+// input field or the detail pane.
+func (t *tui) boardFocused() bool {
+	focus := t.app.GetFocus()
+	if focus == t.table || focus == t.board {
+		return true
+	}
+	for _, list := range t.boardCols {
+		if focus == list {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedRow returns the index in rows of the selection, or -1. An empty
+// column on the board selects its header.
+func (t *tui) selectedRow() int {
+	if !t.kanban {
+		row, _ := t.table.GetSelection()
+		if row < 0 || row >= len(t.rows) {
+			return -1
+		}
+		return row
+	}
+	if t.boardCol >= len(t.boardCols) {
+		return -1
+	}
+	row, _ := t.boardCols[t.boardCol].GetSelection()
+	if r, ok := t.boardCols[t.boardCol].GetCell(row, 0).GetReference().(int); ok {
+		return r
+	}
+	return t.boardHeaders[t.boardCol]
+}
+
+// selectRow moves the selection to a row of rows.
+func (t *tui) selectRow(r int) {
+	if !t.kanban {
+		t.table.Select(r, 0)
+		return
+	}
+	for i := len(t.boardHeaders) - 1; i >= 0; i-- {
+		if r < t.boardHeaders[i] {
+			continue
+		}
+		focused := t.boardFocused()
+		t.boardCol = i
+		row := r - t.boardHeaders[i] - 1
+		if row < 0 {
+			row = 0
+		}
+		t.boardCols[i].Select(row, 0)
+		if focused {
+			t.app.SetFocus(t.view())
+		}
+		return
+	}
+}
+
+func (t *tui) selectionChanged(row, column int) {
+	if t.visualStart >= 0 {
+		t.paintMarks()
+	}
+}
+
+// focusColumn moves the selection to the next or previous column on the
+// board, keeping it about the same height.
+func (t *tui) focusColumn(next bool) {
+	i := t.boardCol - 1
+	if next {
+		i = t.boardCol + 1
+	}
+	if i < 0 || i >= len(t.boardCols) {
+		return
+	}
+	row, _ := t.boardCols[t.boardCol].GetSelection()
+	if n := t.boardCols[i].GetRowCount(); row >= n {
+		row = n - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	t.boardCol = i
+	t.boardCols[i].Select(row, 0)
+	t.app.SetFocus(t.boardCols[i])
+}
+
+// toggleLayout switches between the flat list and the board, keeping the
+// selection.
+func (t *tui) toggleLayout() {
+	sel := t.selectedRow()
+	t.kanban = !t.kanban
+	t.flex.Clear()
+	t.flex.AddItem(t.layout(), 0, 3, t.detail == nil)
+	if t.detail != nil {
+		t.flex.AddItem(t.textbox, 0, 3, true)
+	}
+	t.render()
+	if sel >= 0 && sel < len(t.rows) {
+		t.selectRow(sel)
+	}
+	if t.detail == nil {
+		t.app.SetFocus(t.view())
+	}
+}
+
+// renderBoard draws a list for each column of res on the board, keeping the
+// selection of each list where it can.
+func (t *tui) renderBoard(res *ProjectQueryResponse) {
+	focused := t.boardFocused()
+	var prev []int
+	for _, list := range t.boardCols {
+		row, _ := list.GetSelection()
+		prev = append(prev, row)
+	}
+
+	cols := 0
+	for _, row := range t.rows {
+		if row.card == nil {
+			cols++
+		}
+	}
+	if t.boardCol >= cols {
+		t.boardCol = 0
+	}
+
+	t.board.Clear()
+	t.boardCols = t.boardCols[:0]
+	t.boardHeaders = t.boardHeaders[:0]
+	t.boardCells = make(map[int]*tview.TableCell)
+	// the board only has room for the fields set by :label and :milestone
+	var fields []string
+	for _, f := range t.fields {
+		if f == "labels" || f == "milestone" {
+			fields = append(fields, f)
+		}
+	}
+	var list *tview.Table
+	for r, row := range t.rows {
+		if row.card == nil {
+			i := len(t.boardCols)
+			list = tview.NewTable()
+			list.SetBackgroundColor(tcell.ColorDefault)
+			list.SetSelectedStyle(tcell.Style{}.Reverse(true))
+			list.SetSelectable(true, false)
+			list.SetBorder(true)
+			list.SetTitleColor(tcell.ColorGreen)
+			list.SetSelectedFunc(func(row, column int) {
+				t.showDetail(t.selectedRow())
+			})
+			list.SetSelectionChangedFunc(t.selectionChanged)
+			list.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+				if action == tview.MouseLeftClick {
+					t.boardCol = i
+				}
+				return action, event
+			})
+			t.boardCols = append(t.boardCols, list)
+			t.boardHeaders = append(t.boardHeaders, r)
+			continue
+		}
+		var cell *tview.TableCell
+		if row.card.Content.Number == 0 {
+			cell = tview.NewTableCell(tview.Escape(capStr(oneLine(row.card.Note), 60))).SetTextColor(tcell.ColorGray)
+		} else {
+			text := fmt.Sprintf("[blue]#%d[-] %s", row.card.Content.Number, tview.Escape(row.card.Content.Title))
+			for _, f := range fields {
+				if f == "labels" {
+					text += " " + fieldCell(row.card.Content, f).Text
+				} else if m := fieldText(row.card.Content, f); m != "" {
+					text += " [gray]" + tview.Escape(m) + "[-]"
+				}
+			}
+			cell = tview.NewTableCell(text)
+		}
+		cell.SetReference(r).SetExpansion(1)
+		list.SetCell(list.GetRowCount(), 0, cell)
+		t.boardCells[r] = cell
+	}
+
+	for i, list := range t.boardCols {
+		if i < len(prev) && prev[i] < list.GetRowCount() {
+			list.Select(prev[i], 0)
+		}
+	}
+	if focused {
+		t.app.SetFocus(t.view())
+	}
+}
+
+// scrollBoard puts as many columns on the board as fit in width, scrolling
+// no more than needed to keep boardCol in view. It runs before each draw of
+// the board, when its width is known.
+func (t *tui) scrollBoard(width int) {
+	n := width / minColumnWidth
+	if n < 1 {
+		n = 1
+	}
+	if n > len(t.boardCols) {
+		n = len(t.boardCols)
+	}
+	if t.boardCol < t.boardOffset {
+		t.boardOffset = t.boardCol
+	}
+	if t.boardCol >= t.boardOffset+n {
+		t.boardOffset = t.boardCol - n + 1
+	}
+	if t.boardOffset > len(t.boardCols)-n {
+		t.boardOffset = len(t.boardCols) - n
+	}
+	t.board.Clear()
+	for i := t.boardOffset; i < t.boardOffset+n; i++ {
+		col := t.rows[t.boardHeaders[i]].col
+		title := fmt.Sprintf(" %s (%d) ", tview.Escape(col.Name), len(col.Cards.Nodes))
+		// arrows show there are more columns off the board
+		if i == t.boardOffset && i > 0 {
+			title = "◀" + title
+		}
+		if i == t.boardOffset+n-1 && i+1 < len(t.boardCols) {
+			title += "▶"
+		}
+		t.boardCols[i].SetTitle(title)
+		t.board.AddItem(t.boardCols[i], 0, 1, i == t.boardCol)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseIssueRef parses an issue or pull request given as a URL, owner/repo#n,
+// or #n and n in defaultRepo.
+func parseIssueRef(s, defaultRepo string) (string, int, error) {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) == 4 && (parts[2] == "issues" || parts[2] == "pull") {
+			if n, err := strconv.Atoi(parts[3]); err == nil {
+				return parts[0] + "/" + parts[1], n, nil
+			}
+		}
+		return "", 0, fmt.Errorf("%s is not an issue or pull request URL", s)
+	}
+	repo := defaultRepo
+	if i := strings.LastIndexByte(s, '#'); i >= 0 {
+		if i > 0 {
+			repo = s[:i]
+		}
+		s = s[i+1:]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("invalid issue %q, expected a URL, owner/repo#number or #number", s)
+	}
+	if repo == "" {
+		return "", 0, fmt.Errorf("no repository for #%d, use owner/repo#%d", n, n)
+	}
+	return repo, n, nil
+}
+
+// GetIssue fetches an issue or pull request by repository and number.
+func (c *Client) GetIssue(ctx context.Context, repo string, number int) (Content, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return Content{}, fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	req := c.newRequest(`query getIssue($owner: String!, $name: String!, $number: Int!) {
+		repository(owner: $owner, name: $name) {
+			issueOrPullRequest(number: $number) {
+				...issueFields
+				...pullRequestFields
+				... on Issue {
+					body
+				}
+				... on PullRequest {
+					body
+				}
+			}
+		}
+	}` + contentFragments)
+	req.Var("owner", parts[0])
+	req.Var("name", parts[1])
+	req.Var("number", number)
+
+	res := struct {
+		Repository *struct {
+			IssueOrPullRequest *Content `json:"issueOrPullRequest"`
+		} `json:"repository"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return Content{}, err
+	}
+	if res.Repository == nil || res.Repository.IssueOrPullRequest == nil {
+		return Content{}, fmt.Errorf("couldn't find %s#%d", repo, number)
+	}
+	return *res.Repository.IssueOrPullRequest, nil
+}
+
+// AddCard adds an issue or pull request to the project in the named column,
+// or the first column if colName is empty.
+func (c *Client) AddCard(ctx context.Context, ref ProjectRef, contentID, colName string) error {
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	cols := proj.Owner.Project.Columns.Nodes
+	if colName == "" {
+		if len(cols) == 0 {
+			return fmt.Errorf("project %s has no columns", ref)
+		}
+		colName = cols[0].Name
+	}
+	if ref.V2 {
+		req := c.newRequest(`mutation addItem($projectid: ID!, $contentid: ID!) {
+			addProjectV2ItemById(input: {clientMutationId: "proj", projectId: $projectid, contentId: $contentid}) {
+				item {
+					id
+				}
+			}
+		}`)
+		req.Var("projectid", proj.Owner.Project.ID)
+		req.Var("contentid", contentID)
+
+		res := struct {
+			AddProjectV2ItemByID struct {
+				Item struct {
+					ID string `json:"id"`
+				} `json:"item"`
+			} `json:"addProjectV2ItemById"`
+		}{}
+		if err := c.mutate(ctx, req, &res); err != nil {
+			return err
+		}
+		if matchColumn(noStatusColumn, colName) {
+			return nil
+		}
+		return c.setItemStatus(ctx, proj.Owner.Project, res.AddProjectV2ItemByID.Item.ID, colName)
+	}
+	var colID string
+	for _, col := range cols {
+		if matchColumn(col.Name, colName) {
+			colID = col.ID
+		}
+	}
+	if colID == "" {
+		return fmt.Errorf("no column %q", colName)
+	}
+	req := c.newRequest(`mutation addCard($colid: ID!, $contentid: ID!) {
+		addProjectCard(input: {clientMutationId: "proj", projectColumnId: $colid, contentId: $contentid}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("colid", colID)
+	req.Var("contentid", contentID)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// DeleteCard removes a card or note from the project. The issue or pull
+// request on the card is left as it is.
+func (c *Client) DeleteCard(ctx context.Context, ref ProjectRef, card Node) error {
+	if ref.V2 {
+		projectID, err := c.projectV2ID(ctx, ref)
+		if err != nil {
+			return err
+		}
+		req := c.newRequest(`mutation deleteItem($projectid: ID!, $itemid: ID!) {
+			deleteProjectV2Item(input: {clientMutationId: "proj", projectId: $projectid, itemId: $itemid}) {
+				clientMutationId
+			}
+		}`)
+		req.Var("projectid", projectID)
+		req.Var("itemid", card.ID)
+
+		res := struct{}{}
+		return c.mutate(ctx, req, &res)
+	}
+	req := c.newRequest(`mutation deleteCard($cardid: ID!) {
+		deleteProjectCard(input: {clientMutationId: "proj", cardId: $cardid}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("cardid", card.ID)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// ArchiveCard archives a card, or restores it from the archive if archived
+// is false.
+func (c *Client) ArchiveCard(ctx context.Context, ref ProjectRef, card Node, archived bool) error {
+	return c.ArchiveCards(ctx, ref, []Node{card}, archived)
+}
+
+// CardPosition says where a moved card goes in its column: after the card
+// with the ID After, at the bottom, or otherwise at the top.
+type CardPosition struct {
+	After  string
+	Bottom bool
+}
+
+// MoveCard moves the card of an issue or pull request to the named column,
+// placing it at pos.
+func (c *Client) MoveCard(ctx context.Context, issue Content, ref ProjectRef, colName string, pos CardPosition) error {
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	card, ok := findCard(proj, issue)
+	if !ok {
+		return fmt.Errorf("couldn't move card: %s is not on %s", issue.URL, proj.Owner.Project.Name)
+	}
+	return c.moveCard(ctx, ref, proj, card, colName, pos)
+}
+
+// RepositionCard moves a card, which may be a note, within its column.
+func (c *Client) RepositionCard(ctx context.Context, ref ProjectRef, card Node, pos CardPosition) error {
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	col, _, ok := locateCard(proj, card.ID)
+	if !ok {
+		return fmt.Errorf("couldn't move card: it is no longer on %s", proj.Owner.Project.Name)
+	}
+	return c.moveCard(ctx, ref, proj, card, col.Name, pos)
+}
+
+func (c *Client) moveCard(ctx context.Context, ref ProjectRef, proj *ProjectQueryResponse, card Node, colName string, pos CardPosition) error {
+	col, err := findColumn(proj, colName)
+	if err != nil && ref.V2 && matchColumn(noStatusColumn, colName) {
+		// the No Status column is only listed when it has cards
+		col, err = ColumnNode{Name: noStatusColumn}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't move card: %v", err)
+	}
+	afterID := pos.After
+	if pos.Bottom {
+		for _, other := range col.Cards.Nodes {
+			if other.ID != card.ID {
+				afterID = other.ID
+			}
+		}
+	}
+	if ref.V2 {
+		if err := c.setItemStatus(ctx, proj.Owner.Project, card.ID, col.Name); err != nil {
+			return err
+		}
+		req := c.newRequest(`mutation positionItem($projectid: ID!, $itemid: ID!, $afterid: ID) {
+			updateProjectV2ItemPosition(input: {clientMutationId: "proj", projectId: $projectid, itemId: $itemid, afterId: $afterid}) {
+				clientMutationId
+			}
+		}`)
+		req.Var("projectid", proj.Owner.Project.ID)
+		req.Var("itemid", card.ID)
+		req.Var("afterid", nullable(afterID))
+
+		res := struct{}{}
+		return c.mutate(ctx, req, &res)
+	}
+	req := c.newRequest(`mutation moveCard($cardid: ID!, $colid: ID!, $afterid: ID) {
+		moveProjectCard(input: {clientMutationId: "proj", cardId: $cardid, columnId: $colid, afterCardId: $afterid}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("cardid", card.ID)
+	req.Var("colid", col.ID)
+	req.Var("afterid", nullable(afterID))
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// locateCard returns the column a card is in and its index there.
+func locateCard(res *ProjectQueryResponse, cardID string) (ColumnNode, int, bool) {
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		for i, card := range col.Cards.Nodes {
+			if card.ID == cardID {
+				return col, i, true
+			}
+		}
+	}
+	return ColumnNode{}, 0, false
+}
+
+// findCard finds the card for an issue or pull request on the board.
+func findCard(res *ProjectQueryResponse, issue Content) (Node, bool) {
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		for _, card := range col.Cards.Nodes {
+			if card.Content.ID != "" && card.Content.ID == issue.ID {
+				return card, true
+			}
+		}
+	}
+	return Node{}, false
+}
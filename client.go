@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+const (
+	defaultHost      = "github.com"
+	defaultEndpoint  = "https://api.github.com/graphql"
+	defaultUserAgent = "proj"
+)
+
+// Client talks to the GitHub GraphQL API. All requests made through it share
+// the same endpoint, credentials and HTTP transport.
+type Client struct {
+	Host       string
+	Endpoint   string
+	Token      string
+	UserAgent  string
+	HTTPClient *http.Client
+
+	gql *graphql.Client
+}
+
+type ClientOption func(*Client)
+
+// WithHost points the client at a GitHub Enterprise Server instance, or at
+// github.com if host is empty.
+func WithHost(host string) ClientOption {
+	return func(c *Client) {
+		c.Host = normalizeHost(host)
+		c.Endpoint = endpointForHost(c.Host)
+	}
+}
+
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *Client) {
+		c.Endpoint = endpoint
+	}
+}
+
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = hc
+	}
+}
+
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) {
+		c.UserAgent = ua
+	}
+}
+
+func NewClient(token string, opts ...ClientOption) *Client {
+	c := &Client{
+		Host:       defaultHost,
+		Endpoint:   defaultEndpoint,
+		Token:      token,
+		UserAgent:  defaultUserAgent,
+		HTTPClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	hc := *c.HTTPClient
+	hc.Transport = newRetryTransport(hc.Transport)
+	c.gql = graphql.NewClient(c.Endpoint, graphql.WithHTTPClient(&hc))
+	return c
+}
+
+func (c *Client) newRequest(q string) *graphql.Request {
+	req := graphql.NewRequest(q)
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("User-Agent", c.UserAgent)
+	return req
+}
+
+// query runs a request that doesn't modify anything, so it can be retried
+// freely.
+func (c *Client) query(ctx context.Context, req *graphql.Request, resp interface{}) error {
+	return c.gql.Run(withIdempotent(ctx), req, resp)
+}
+
+func (c *Client) mutate(ctx context.Context, req *graphql.Request, resp interface{}) error {
+	return c.gql.Run(ctx, req, resp)
+}
+
+func normalizeHost(host string) string {
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimSuffix(strings.ToLower(host), "/")
+	if host == "" || host == "api.github.com" {
+		return defaultHost
+	}
+	return host
+}
+
+func isEnterprise(host string) bool {
+	return normalizeHost(host) != defaultHost
+}
+
+func endpointForHost(host string) string {
+	if !isEnterprise(host) {
+		return defaultEndpoint
+	}
+	return "https://" + normalizeHost(host) + "/api/graphql"
+}
+
+// tokenForHost returns the token from the environment that matches host,
+// following the same variables as the gh CLI.
+func tokenForHost(host string) string {
+	if isEnterprise(host) {
+		if t := os.Getenv("GH_ENTERPRISE_TOKEN"); t != "" {
+			return t
+		}
+		return os.Getenv("GITHUB_ENTERPRISE_TOKEN")
+	}
+	if t := os.Getenv("GH_TOKEN"); t != "" {
+		return t
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// errV2Columns is returned by the column commands for ProjectV2 boards,
+// where columns are the options of the status field.
+var errV2Columns = fmt.Errorf("columns of v2 projects are options of the status field, edit them in the project settings on GitHub")
+
+// findColumn returns the column of the project named name.
+func findColumn(res *ProjectQueryResponse, name string) (ColumnNode, error) {
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		if matchColumn(col.Name, name) {
+			return col, nil
+		}
+	}
+	return ColumnNode{}, fmt.Errorf("no column %q in %s", name, res.Owner.Project.Name)
+}
+
+// AddColumn adds a column named name at the right of the board.
+func (c *Client) AddColumn(ctx context.Context, ref ProjectRef, name string) error {
+	if ref.V2 {
+		return errV2Columns
+	}
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	req := c.newRequest(`mutation addColumn($projectid: ID!, $name: String!) {
+		addProjectColumn(input: {clientMutationId: "proj", projectId: $projectid, name: $name}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("projectid", proj.Owner.Project.ID)
+	req.Var("name", name)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// RenameColumn renames the column colName to name.
+func (c *Client) RenameColumn(ctx context.Context, ref ProjectRef, colName, name string) error {
+	if ref.V2 {
+		return errV2Columns
+	}
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	col, err := findColumn(proj, colName)
+	if err != nil {
+		return err
+	}
+	req := c.newRequest(`mutation renameColumn($colid: ID!, $name: String!) {
+		updateProjectColumn(input: {clientMutationId: "proj", projectColumnId: $colid, name: $name}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("colid", col.ID)
+	req.Var("name", name)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// MoveColumn moves the column colName to the right of the column after, or
+// to the far left if after is empty.
+func (c *Client) MoveColumn(ctx context.Context, ref ProjectRef, colName, after string) error {
+	if ref.V2 {
+		return errV2Columns
+	}
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	col, err := findColumn(proj, colName)
+	if err != nil {
+		return err
+	}
+	var afterID string
+	if after != "" {
+		afterCol, err := findColumn(proj, after)
+		if err != nil {
+			return err
+		}
+		afterID = afterCol.ID
+	}
+	req := c.newRequest(`mutation moveColumn($colid: ID!, $afterid: ID) {
+		moveProjectColumn(input: {clientMutationId: "proj", columnId: $colid, afterColumnId: $afterid}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("colid", col.ID)
+	req.Var("afterid", nullable(afterID))
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// CountColumnCards counts the cards in a column, archived ones included, as
+// they go with it when it's deleted.
+func (c *Client) CountColumnCards(ctx context.Context, ref ProjectRef, col ColumnNode) (int, error) {
+	if ref.V2 {
+		return 0, errV2Columns
+	}
+	req := c.newRequest(`query countCards($colid: ID!) {
+		node(id: $colid) {
+			... on ProjectColumn {
+				cards(first: 0, archivedStates: [ARCHIVED, NOT_ARCHIVED]) {
+					totalCount
+				}
+			}
+		}
+	}`)
+	req.Var("colid", col.ID)
+
+	res := struct {
+		Node *struct {
+			Cards struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"cards"`
+		} `json:"node"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return 0, err
+	}
+	if res.Node == nil {
+		return 0, fmt.Errorf("couldn't find column %s", col.Name)
+	}
+	return res.Node.Cards.TotalCount, nil
+}
+
+// DeleteColumn deletes a column along with the cards in it. The issues on
+// the cards are left as they are.
+func (c *Client) DeleteColumn(ctx context.Context, ref ProjectRef, col ColumnNode) error {
+	if ref.V2 {
+		return errV2Columns
+	}
+	req := c.newRequest(`mutation deleteColumn($colid: ID!) {
+		deleteProjectColumn(input: {clientMutationId: "proj", columnId: $colid}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("colid", col.ID)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
@@ -0,0 +1,731 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// env holds what commands need from the global flags.
+type env struct {
+	client *Client
+	ref    ProjectRef
+	list   listOptions
+}
+
+// listOptions are the flags shared by list, view and tui, which can be
+// given before or after the command name.
+type listOptions struct {
+	user     string
+	filter   string
+	format   string
+	template string
+	show     string
+	archived bool
+}
+
+func (o *listOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.user, "u", o.user, "filter by user")
+	fs.StringVar(&o.filter, "f", o.filter, "filter cards, e.g. \"label:bug -state:closed\"")
+	fs.StringVar(&o.filter, "filter", o.filter, "filter cards, e.g. \"label:bug -state:closed\"")
+	fs.StringVar(&o.format, "format", o.format, "output format: "+strings.Join(formats, ", "))
+	fs.StringVar(&o.template, "template", o.template, "Go template for --format template, with .Project, .Columns and .Cards, or @file")
+	fs.StringVar(&o.show, "show", o.show, "extra columns to show: "+strings.Join(cardFields, ", "))
+	fs.BoolVar(&o.archived, "archived", o.archived, "show archived cards instead of the active ones")
+}
+
+func (o *listOptions) parse() (*Filter, []string, error) {
+	expr := o.filter
+	if o.user != "" {
+		expr = fmt.Sprintf("owner:%q %s", o.user, expr)
+	}
+	filter, err := ParseFilter(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, err := parseFields(o.show)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, fields, nil
+}
+
+// issue looks up an issue or pull request given as a URL, owner/repo#n or #n.
+func (e *env) issue(ctx context.Context, arg string) (Content, error) {
+	repo, number, err := parseIssueRef(arg, e.ref.Repo())
+	if err != nil {
+		return Content{}, err
+	}
+	return e.client.GetIssue(ctx, repo, number)
+}
+
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"list", "[flags]", "list the cards on the board, the default command", runList},
+	{"tui", "[flags]", "browse and edit the board interactively", runTUI},
+	{"view", "<issue>", "show an issue or pull request", runView},
+	{"move", "<issue> [column] [flags]", "move a card to another column, or within its column", runMove},
+	{"assign", "<user> <issue>...", "assign a user to issues or pull requests", runAssign},
+	{"unassign", "<user> <issue>...", "remove a user from issues or pull requests", runUnassign},
+	{"close", "<issue>...", "close issues", runClose},
+	{"reopen", "<issue>...", "reopen issues", runReopen},
+	{"edit", "[-y] <issue>", "edit the title and body of an issue or pull request in $EDITOR", runEdit},
+	{"label", "<issue>... +<label> -<label>...", "add and remove labels on issues or pull requests", runLabel},
+	{"milestone", "<title>|--clear <issue>...", "set or clear the milestone of issues or pull requests", runMilestone},
+	{"new", "[flags]", "create an issue on the board, in $EDITOR without --title", runNew},
+	{"add", "[-c column] <issue>...", "add issues or pull requests to the board", runAdd},
+	{"remove", "[--archive | -y] <issue>...", "take cards off the board, asking first, or archive them", runRemove},
+	{"archive", "<issue>...", "archive cards, see list --archived", runArchive},
+	{"unarchive", "<issue>...", "restore archived cards", runUnarchive},
+	{"column", "add <name>", "add a column at the right of the board", runColumn},
+	{"column", "rename <column> <name>", "rename a column", runColumn},
+	{"column", "move <column> [--after column]", "move a column, to the far left without --after", runColumn},
+	{"column", "delete [-y] <column>", "delete a column and its cards, asking first if it has any", runColumn},
+	{"comment", "<issue> [-m text | -F file]", "comment on an issue or pull request, in $EDITOR without -m or -F", runComment},
+	{"comment", "<issue>|<comment> --edit | --delete", "edit or delete a comment, given by URL or ID, or your latest one", runComment},
+	{"note", "add <column> <text>", "add a note, or a draft issue on v2 projects", runNote},
+	{"note", "edit <note> <text>", "replace the text of a note", runNote},
+	{"note", "delete <note>", "delete a note", runNote},
+	{"note", "convert <note> <owner/repo> [title]", "turn a note into an issue in the repository", runNote},
+}
+
+const commandHelp = `An issue is given by its URL, as owner/repo#number, or as #number for
+repository projects. A note is given by its card id, as listed by --format
+json, or by part of its text. Run a command with -h for its flags.`
+
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-42s %s\n", cmd.name+" "+cmd.usage, cmd.help)
+	}
+	fmt.Fprintf(w, "\n%s\n", commandHelp)
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	opts := e.list
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	opts.register(fs)
+	fs.Parse(args)
+	filter, fields, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	ref := e.ref
+	ref.Archived = opts.archived
+	res, err := e.client.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if !filter.Empty() {
+		res = filterCards(res, filter.Match)
+	}
+	out := outputOptions{Format: opts.format, Template: opts.template, Width: outputWidth(os.Stdout), Fields: fields}
+	if err := writeProject(os.Stdout, res, out); err != nil {
+		return err
+	}
+	if res.Truncated {
+		fmt.Fprintf(os.Stderr, "warning: %s has too many cards, some were not listed\n", res.Owner.Project.Name)
+	}
+	if isatty.IsTerminal(os.Stderr.Fd()) && res.RateLimit.Limit > 0 {
+		fmt.Fprintln(os.Stderr, color.HiBlackString(res.RateLimit.String()))
+	}
+	return nil
+}
+
+func runTUI(ctx context.Context, e *env, args []string) error {
+	opts := e.list
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	opts.register(fs)
+	board := fs.Bool("board", false, "show the columns side by side, b switches layouts")
+	fs.Parse(args)
+	filter, fields, err := opts.parse()
+	if err != nil {
+		return err
+	}
+	ref := e.ref
+	ref.Archived = opts.archived
+	return doTUI(ctx, e.client, ref, tuiOptions{Filter: filter, Fields: fields, Kanban: *board})
+}
+
+func runView(ctx context.Context, e *env, args []string) error {
+	opts := e.list
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	opts.register(fs)
+	args = parseArgs(fs, args)
+	if len(args) != 1 {
+		return fmt.Errorf("usage: view <issue>")
+	}
+	issue, err := e.issue(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if opts.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(issue)
+	}
+	writeIssue(os.Stdout, issue)
+	return nil
+}
+
+// writeIssue prints a summary of an issue or pull request followed by its
+// body.
+func writeIssue(w io.Writer, issue Content) {
+	fmt.Fprintf(w, "%s %s\n", color.New(color.Bold).Sprint(issue.Title), color.BlueString("#%d", issue.Number))
+	fmt.Fprintf(w, "%s · %s opened %s · %s\n", cardState(issue), issue.Author.Login, formatDate(issue.CreatedAt), issue.Repository.NameWithOwner)
+	var assignees, labels []string
+	for _, a := range issue.Assignees.Edges {
+		assignees = append(assignees, a.Node.Login)
+	}
+	for _, l := range issue.Labels.Nodes {
+		labels = append(labels, labelColor(l))
+	}
+	if len(assignees) > 0 {
+		fmt.Fprintf(w, "Assignees: %s\n", strings.Join(assignees, ", "))
+	}
+	if len(labels) > 0 {
+		fmt.Fprintf(w, "Labels: %s\n", strings.Join(labels, ", "))
+	}
+	if issue.Milestone != nil {
+		fmt.Fprintf(w, "Milestone: %s\n", issue.Milestone.Title)
+	}
+	fmt.Fprintln(w, color.CyanString(issue.URL))
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		fmt.Fprintf(w, "\n%s\n", body)
+	}
+}
+
+func runMove(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	after := fs.String("after", "", "place the card below this issue")
+	top := fs.Bool("top", false, "place the card at the top of the column, the default")
+	bottom := fs.Bool("bottom", false, "place the card at the bottom of the column")
+	args = parseArgs(fs, args)
+	if len(args) == 0 || *top && *bottom || *after != "" && (*top || *bottom) {
+		return fmt.Errorf("usage: move <issue> [column] [--after issue | --top | --bottom]")
+	}
+	issue, err := e.issue(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	colName := strings.Join(args[1:], " ")
+	pos := CardPosition{Bottom: *bottom}
+	if *after != "" || colName == "" {
+		// without a column the card stays in its own, or joins the --after card
+		res, err := e.client.GetProject(ctx, e.ref)
+		if err != nil {
+			return err
+		}
+		of := issue
+		if *after != "" {
+			if of, err = e.issue(ctx, *after); err != nil {
+				return err
+			}
+		}
+		card, ok := findCard(res, of)
+		if !ok {
+			return fmt.Errorf("%s is not on %s", of.URL, res.Owner.Project.Name)
+		}
+		col, _, _ := locateCard(res, card.ID)
+		if colName == "" {
+			colName = col.Name
+		}
+		if *after != "" {
+			pos.After = card.ID
+		}
+	}
+	return e.client.MoveCard(ctx, issue, e.ref, colName, pos)
+}
+
+func runAssign(ctx context.Context, e *env, args []string) error {
+	return forEachIssue(ctx, e, args, 1, "assign <user> <issue>...", func(issue Content) error {
+		return e.client.AssignIssue(ctx, args[0], issue)
+	})
+}
+
+func runUnassign(ctx context.Context, e *env, args []string) error {
+	return forEachIssue(ctx, e, args, 1, "unassign <user> <issue>...", func(issue Content) error {
+		return e.client.UnassignIssue(ctx, args[0], issue)
+	})
+}
+
+func runClose(ctx context.Context, e *env, args []string) error {
+	return forEachIssue(ctx, e, args, 0, "close <issue>...", func(issue Content) error {
+		if strings.Contains(issue.URL, "pull") {
+			return fmt.Errorf("can't close %s, only issues can be closed", issue.URL)
+		}
+		return e.client.CloseIssue(ctx, issue)
+	})
+}
+
+func runReopen(ctx context.Context, e *env, args []string) error {
+	return forEachIssue(ctx, e, args, 0, "reopen <issue>...", func(issue Content) error {
+		if strings.Contains(issue.URL, "pull") {
+			return fmt.Errorf("can't reopen %s, only issues can be reopened", issue.URL)
+		}
+		return e.client.ReopenIssue(ctx, issue)
+	})
+}
+
+func runEdit(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	yes := fs.Bool("y", false, "overwrite changes made on GitHub while editing without asking")
+	args = parseArgs(fs, args)
+	if len(args) != 1 {
+		return fmt.Errorf("usage: edit [-y] <issue>")
+	}
+	issue, err := e.issue(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	text, err := editText(editForm(issue))
+	if err != nil {
+		return err
+	}
+	title, body, err := parseEditForm(text)
+	if err != nil {
+		return err
+	}
+	if title == "" {
+		return fmt.Errorf("no title, not saving")
+	}
+	newTitle, newBody := diffIssue(issue, title, body)
+	if newTitle == nil && newBody == nil {
+		fmt.Fprintln(os.Stderr, "no changes")
+		return nil
+	}
+	fresh, err := e.issue(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if editConflict(issue, fresh) && !*yes && !confirm(fmt.Sprintf("%s was edited on GitHub while you were editing, overwrite it?", issue.URL)) {
+		return nil
+	}
+	if err := e.client.UpdateIssue(ctx, issue, newTitle, newBody); err != nil {
+		return err
+	}
+	fmt.Printf("%s: changed %s\n", issue.URL, describeEdit(issue, newTitle, newBody))
+	return nil
+}
+
+func runLabel(ctx context.Context, e *env, args []string) error {
+	var refs, labels []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+			labels = append(labels, arg)
+		} else {
+			refs = append(refs, arg)
+		}
+	}
+	if len(refs) == 0 || len(labels) == 0 {
+		return fmt.Errorf("usage: label <issue>... +<label> -<label>...")
+	}
+	var issues []Content
+	if err := forEachIssue(ctx, e, refs, 0, "", func(issue Content) error {
+		issues = append(issues, issue)
+		return nil
+	}); err != nil {
+		return err
+	}
+	add, remove := parseLabelArgs(labels)
+	_, err := e.client.LabelIssues(ctx, issues, add, remove)
+	return err
+}
+
+func runMilestone(ctx context.Context, e *env, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: milestone <title>|--clear <issue>...")
+	}
+	title := args[0]
+	if title == "--clear" {
+		title = ""
+	}
+	var issues []Content
+	if err := forEachIssue(ctx, e, args, 1, "", func(issue Content) error {
+		issues = append(issues, issue)
+		return nil
+	}); err != nil {
+		return err
+	}
+	_, err := e.client.SetMilestone(ctx, issues, title)
+	return err
+}
+
+// forEachIssue looks up the issues in args after the first skip arguments
+// and calls fn on each.
+func forEachIssue(ctx context.Context, e *env, args []string, skip int, usage string, fn func(Content) error) error {
+	if len(args) <= skip {
+		return fmt.Errorf("usage: %s", usage)
+	}
+	for _, arg := range args[skip:] {
+		issue, err := e.issue(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if err := fn(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runNote(ctx context.Context, e *env, args []string) error {
+	usage := fmt.Errorf(`usage: note add <column> <text>
+       note edit <note> <text>
+       note delete <note>
+       note convert <note> <owner/repo> [title]`)
+	if len(args) < 2 {
+		return usage
+	}
+	if args[0] == "add" {
+		if len(args) < 3 {
+			return usage
+		}
+		return e.client.AddNote(ctx, e.ref, args[1], strings.Join(args[2:], " "))
+	}
+	res, err := e.client.GetProject(ctx, e.ref)
+	if err != nil {
+		return err
+	}
+	note, err := findNote(res, args[1])
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "edit":
+		if len(args) < 3 {
+			return usage
+		}
+		return e.client.EditNote(ctx, e.ref, note, strings.Join(args[2:], " "))
+	case "delete":
+		return e.client.DeleteCard(ctx, e.ref, note)
+	case "convert":
+		if len(args) < 3 {
+			return usage
+		}
+		url, err := e.client.ConvertNote(ctx, e.ref, note, args[2], strings.Join(args[3:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Println(url)
+		return nil
+	}
+	return usage
+}
+
+func runComment(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("comment", flag.ExitOnError)
+	message := fs.String("m", "", "the comment text")
+	file := fs.String("F", "", "read the comment from a file, or - for stdin")
+	edit := fs.Bool("edit", false, "edit the comment, or your latest comment on the issue, instead of adding one")
+	del := fs.Bool("delete", false, "delete the comment, or your latest comment on the issue")
+	yes := fs.Bool("y", false, "delete without asking")
+	args = parseArgs(fs, args)
+	if len(args) != 1 || *edit && *del || *message != "" && *file != "" || *del && (*message != "" || *file != "") {
+		return fmt.Errorf("usage: comment <issue> [-m text | -F file] [--edit | --delete [-y]], where --edit and --delete also take a comment URL or ID")
+	}
+	if !*edit && !*del {
+		repo, number, err := parseIssueRef(args[0], e.ref.Repo())
+		if err != nil {
+			return err
+		}
+		issue, err := e.client.GetIssue(ctx, repo, number)
+		if err != nil {
+			return err
+		}
+		body, err := readComment(*message, *file, "")
+		if err != nil {
+			return err
+		}
+		if body == "" {
+			return fmt.Errorf("empty comment, not posting")
+		}
+		url, err := e.client.AddComment(ctx, issue.ID, body)
+		if err != nil {
+			return err
+		}
+		fmt.Println(url)
+		return nil
+	}
+
+	var comment TimelineItem
+	if isCommentRef(args[0]) {
+		var err error
+		if comment, err = e.client.GetComment(ctx, args[0]); err != nil {
+			return err
+		}
+	} else {
+		repo, number, err := parseIssueRef(args[0], e.ref.Repo())
+		if err != nil {
+			return err
+		}
+		detail, err := e.client.GetIssueDetail(ctx, repo, number)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if comment, ok = lastOwnComment(detail); !ok {
+			return fmt.Errorf("you haven't commented on %s, give the URL of a comment to change", detail.URL)
+		}
+	}
+	if *del {
+		if !*yes && !confirm(fmt.Sprintf("delete the comment by %s %s?", login(comment.Author), comment.URL)) {
+			return nil
+		}
+		return e.client.DeleteComment(ctx, comment.ID)
+	}
+	body, err := readComment(*message, *file, comment.Body)
+	if err != nil {
+		return err
+	}
+	if body == "" || body == strings.TrimSpace(comment.Body) {
+		fmt.Fprintln(os.Stderr, "no changes")
+		return nil
+	}
+	return e.client.EditComment(ctx, comment.ID, body)
+}
+
+func runNew(ctx context.Context, e *env, args []string) error {
+	issue := NewIssue{Repo: e.ref.Repo()}
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	fs.StringVar(&issue.Repo, "r", issue.Repo, "repository to create the issue in, as owner/repo")
+	fs.StringVar(&issue.Repo, "repo", issue.Repo, "repository to create the issue in, as owner/repo")
+	fs.StringVar(&issue.Column, "c", "", "column to add the card to, the first column if unset")
+	fs.StringVar(&issue.Column, "column", "", "column to add the card to, the first column if unset")
+	fs.StringVar(&issue.Title, "title", "", "issue title, the issue is written in $EDITOR if unset")
+	fs.StringVar(&issue.Body, "body", "", "issue body")
+	fs.Var((*listFlag)(&issue.Assignees), "assignee", "assign a user, may be repeated or comma separated")
+	fs.Var((*listFlag)(&issue.Labels), "label", "add a label, may be repeated or comma separated")
+	if args = parseArgs(fs, args); len(args) > 0 {
+		return fmt.Errorf("usage: new [flags], pass the title with --title")
+	}
+
+	if issue.Title == "" {
+		text, err := editText(issueForm(issue))
+		if err != nil {
+			return err
+		}
+		issue, err = parseIssueForm(text)
+		if err != nil {
+			return err
+		}
+		if issue.Title == "" {
+			return fmt.Errorf("no title, not creating an issue")
+		}
+	}
+	if issue.Repo == "" {
+		return fmt.Errorf("no repository, use -r owner/repo")
+	}
+	created, err := e.client.CreateIssue(ctx, e.ref, issue)
+	if err != nil {
+		return err
+	}
+	fmt.Println(created.URL)
+	return nil
+}
+
+func runAdd(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	column := fs.String("c", "", "column to add the cards to, the first column if unset")
+	fs.StringVar(column, "column", "", "column to add the cards to, the first column if unset")
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		return fmt.Errorf("usage: add [-c column] <issue>...")
+	}
+	for _, arg := range args {
+		issue, err := e.issue(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if err := e.client.AddCard(ctx, e.ref, issue.ID, *column); err != nil {
+			return fmt.Errorf("couldn't add %s: %v", issue.URL, err)
+		}
+	}
+	return nil
+}
+
+func runRemove(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	archive := fs.Bool("archive", false, "archive the cards instead of deleting them")
+	yes := fs.Bool("y", false, "delete without asking")
+	args = parseArgs(fs, args)
+	if len(args) == 0 {
+		return fmt.Errorf("usage: remove [--archive | -y] <issue>...")
+	}
+	res, err := e.client.GetProject(ctx, e.ref)
+	if err != nil {
+		return err
+	}
+	var cards []Node
+	for _, arg := range args {
+		issue, err := e.issue(ctx, arg)
+		if err != nil {
+			return err
+		}
+		card, ok := findCard(res, issue)
+		if !ok {
+			return fmt.Errorf("%s is not on %s", issue.URL, res.Owner.Project.Name)
+		}
+		cards = append(cards, card)
+	}
+	if !*archive && !*yes {
+		if !confirm(fmt.Sprintf("delete %d card(s) from %s?", len(cards), res.Owner.Project.Name)) {
+			return fmt.Errorf("not deleting")
+		}
+	}
+	for _, card := range cards {
+		if *archive {
+			err = e.client.ArchiveCard(ctx, e.ref, card, true)
+		} else {
+			err = e.client.DeleteCard(ctx, e.ref, card)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runArchive(ctx context.Context, e *env, args []string) error {
+	return archiveCards(ctx, e, args, true)
+}
+
+func runUnarchive(ctx context.Context, e *env, args []string) error {
+	return archiveCards(ctx, e, args, false)
+}
+
+func archiveCards(ctx context.Context, e *env, args []string, archive bool) error {
+	if len(args) == 0 {
+		if archive {
+			return fmt.Errorf("usage: archive <issue>...")
+		}
+		return fmt.Errorf("usage: unarchive <issue>...")
+	}
+	// cards to archive are among the active ones, and the other way round
+	ref := e.ref
+	ref.Archived = !archive
+	res, err := e.client.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	var cards []Node
+	for _, arg := range args {
+		issue, err := e.issue(ctx, arg)
+		if err != nil {
+			return err
+		}
+		card, ok := findCard(res, issue)
+		if !ok && archive {
+			return fmt.Errorf("%s is not on %s", issue.URL, res.Owner.Project.Name)
+		}
+		if !ok {
+			return fmt.Errorf("%s is not archived on %s", issue.URL, res.Owner.Project.Name)
+		}
+		cards = append(cards, card)
+	}
+	return e.client.ArchiveCards(ctx, e.ref, cards, archive)
+}
+
+func runColumn(ctx context.Context, e *env, args []string) error {
+	usage := fmt.Errorf(`usage: column add <name>
+       column rename <column> <name>
+       column move <column> [--after column]
+       column delete [-y] <column>`)
+	if len(args) == 0 {
+		return usage
+	}
+	fs := flag.NewFlagSet("column "+args[0], flag.ExitOnError)
+	after := fs.String("after", "", "column to move the column after")
+	yes := fs.Bool("y", false, "delete without asking")
+	rest := parseArgs(fs, args[1:])
+	switch {
+	case args[0] == "add" && len(rest) == 1:
+		return e.client.AddColumn(ctx, e.ref, rest[0])
+	case args[0] == "rename" && len(rest) == 2:
+		return e.client.RenameColumn(ctx, e.ref, rest[0], rest[1])
+	case args[0] == "move" && len(rest) == 1:
+		return e.client.MoveColumn(ctx, e.ref, rest[0], *after)
+	case args[0] == "delete" && len(rest) == 1:
+		if e.ref.V2 {
+			return errV2Columns
+		}
+		res, err := e.client.GetProject(ctx, e.ref)
+		if err != nil {
+			return err
+		}
+		col, err := findColumn(res, rest[0])
+		if err != nil {
+			return err
+		}
+		n, err := e.client.CountColumnCards(ctx, e.ref, col)
+		if err != nil {
+			return err
+		}
+		if n > 0 && !*yes {
+			if !confirm(fmt.Sprintf("%s has %d card(s), delete it anyway?", col.Name, n)) {
+				return fmt.Errorf("not deleting %s", col.Name)
+			}
+		}
+		return e.client.DeleteColumn(ctx, e.ref, col)
+	}
+	return usage
+}
+
+// confirm asks a yes or no question on the terminal, defaulting to no.
+func confirm(prompt string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// parseArgs parses the flags in args, allowing them to follow positional
+// arguments as in "add URL -c Done", and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// listFlag collects the values of a flag that can be repeated or given a
+// comma separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, splitList(s)...)
+	return nil
+}
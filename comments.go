@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AddComment comments on an issue or pull request and returns the URL of
+// the comment.
+func (c *Client) AddComment(ctx context.Context, subjectID, body string) (string, error) {
+	req := c.newRequest(`mutation addComment($subjectid: ID!, $body: String!) {
+		addComment(input: {clientMutationId: "proj", subjectId: $subjectid, body: $body}) {
+			commentEdge {
+				node {
+					url
+				}
+			}
+		}
+	}`)
+	req.Var("subjectid", subjectID)
+	req.Var("body", body)
+
+	res := struct {
+		AddComment struct {
+			CommentEdge struct {
+				Node struct {
+					URL string `json:"url"`
+				} `json:"node"`
+			} `json:"commentEdge"`
+		} `json:"addComment"`
+	}{}
+	if err := c.mutate(ctx, req, &res); err != nil {
+		return "", err
+	}
+	return res.AddComment.CommentEdge.Node.URL, nil
+}
+
+// EditComment replaces the body of a comment.
+func (c *Client) EditComment(ctx context.Context, commentID, body string) error {
+	req := c.newRequest(`mutation editComment($id: ID!, $body: String!) {
+		updateIssueComment(input: {clientMutationId: "proj", id: $id, body: $body}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("id", commentID)
+	req.Var("body", body)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// DeleteComment deletes a comment.
+func (c *Client) DeleteComment(ctx context.Context, commentID string) error {
+	req := c.newRequest(`mutation deleteComment($id: ID!) {
+		deleteIssueComment(input: {clientMutationId: "proj", id: $id}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("id", commentID)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// GetComment fetches a comment on an issue or pull request by its URL or
+// node ID.
+func (c *Client) GetComment(ctx context.Context, ref string) (TimelineItem, error) {
+	vars, root := "$ref: ID!", "node(id: $ref)"
+	if strings.Contains(ref, "/") {
+		vars, root = "$ref: URI!", "resource(url: $ref)"
+	}
+	req := c.newRequest(fmt.Sprintf(`query getComment(%s) {
+		comment: %s {
+			__typename
+			... on IssueComment {
+				id
+				author {
+					login
+				}
+				body
+				createdAt
+				url
+				viewerDidAuthor
+			}
+		}
+	}`, vars, root))
+	req.Var("ref", ref)
+
+	res := struct {
+		Comment *TimelineItem `json:"comment"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return TimelineItem{}, err
+	}
+	if res.Comment == nil || res.Comment.Type != "IssueComment" {
+		return TimelineItem{}, fmt.Errorf("couldn't find comment %s", ref)
+	}
+	return *res.Comment, nil
+}
+
+// isCommentRef reports whether s is the URL or node ID of a comment rather
+// than an issue.
+func isCommentRef(s string) bool {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		return strings.HasPrefix(u.Fragment, "issuecomment-")
+	}
+	return strings.HasPrefix(s, "IC_")
+}
+
+// lastOwnComment returns the latest comment by the viewer in the timeline.
+func lastOwnComment(d IssueDetail) (TimelineItem, bool) {
+	items := d.TimelineItems.Nodes
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == "IssueComment" && items[i].ViewerDidAuthor {
+			return items[i], true
+		}
+	}
+	return TimelineItem{}, false
+}
+
+// readComment returns the text of a comment from -m, or the file named by
+// -F, or else written in the user's editor starting from current.
+func readComment(message, file, current string) (string, error) {
+	switch {
+	case message != "":
+		return strings.TrimSpace(message), nil
+	case file == "-":
+		b, err := ioutil.ReadAll(os.Stdin)
+		return strings.TrimSpace(string(b)), err
+	case file != "":
+		b, err := ioutil.ReadFile(file)
+		return strings.TrimSpace(string(b)), err
+	}
+	text, err := editText(current)
+	return strings.TrimSpace(text), err
+}
+
+// commentCommand runs :comment, which posts text as a comment on the open
+// or selected issue, writing it in the user's editor if there's no text.
+// With --edit or --delete it acts on the comment selected in the detail
+// pane instead, or the user's latest comment.
+func (t *tui) commentCommand(args []string) error {
+	issue := t.currentIssue()
+	if issue.Number == 0 {
+		t.setMessage("select an issue or pull request to comment on")
+		return nil
+	}
+	edit := len(args) > 0 && args[0] == "--edit"
+	del := len(args) > 0 && args[0] == "--delete"
+	if !edit && !del {
+		body := strings.Join(args, " ")
+		if body == "" {
+			var err error
+			if body, err = t.editComment(t.drafts[issue.ID]); err != nil {
+				return err
+			}
+		}
+		if body == "" {
+			delete(t.drafts, issue.ID)
+			t.setMessage("empty comment, not posting")
+			return nil
+		}
+		t.setMessage(fmt.Sprintf("commenting on #%d", issue.Number))
+		if _, err := t.client.AddComment(t.ctx, issue.ID, body); err != nil {
+			// keep the text for :retry or the next :comment
+			t.drafts[issue.ID] = body
+			return err
+		}
+		delete(t.drafts, issue.ID)
+		if err := t.reloadDetail(issue, true); err != nil {
+			return err
+		}
+		t.setMessage(fmt.Sprintf("commented on #%d", issue.Number))
+		return nil
+	}
+
+	comment, ok := t.selectedComment()
+	if !ok || t.detail.ID != issue.ID {
+		detail, err := t.client.GetIssueDetail(t.ctx, issue.Repository.NameWithOwner, issue.Number)
+		if err != nil {
+			return err
+		}
+		if comment, ok = lastOwnComment(detail); !ok {
+			t.setMessage(fmt.Sprintf("you haven't commented on #%d, select a comment with [ and ] in the detail pane", issue.Number))
+			return nil
+		}
+	}
+	if del {
+		t.confirm(fmt.Sprintf("delete the comment by %s from %s on #%d?", login(comment.Author), formatDate(comment.CreatedAt), issue.Number), func() error {
+			t.setMessage("deleting comment")
+			if err := t.client.DeleteComment(t.ctx, comment.ID); err != nil {
+				return err
+			}
+			t.commentID = ""
+			if err := t.reloadDetail(issue, false); err != nil {
+				return err
+			}
+			t.setMessage("deleted comment")
+			return nil
+		})
+		return nil
+	}
+	draft, ok := t.drafts[comment.ID]
+	if !ok {
+		draft = comment.Body
+	}
+	body, err := t.editComment(draft)
+	if err != nil {
+		return err
+	}
+	if body == "" || body == strings.TrimSpace(comment.Body) {
+		delete(t.drafts, comment.ID)
+		t.setMessage("no changes")
+		return nil
+	}
+	t.setMessage("editing comment")
+	if err := t.client.EditComment(t.ctx, comment.ID, body); err != nil {
+		t.drafts[comment.ID] = body
+		return err
+	}
+	delete(t.drafts, comment.ID)
+	if err := t.reloadDetail(issue, false); err != nil {
+		return err
+	}
+	t.setMessage("edited comment")
+	return nil
+}
+
+func (t *tui) editComment(text string) (string, error) {
+	var err error
+	t.app.Suspend(func() {
+		text, err = editText(text)
+	})
+	return strings.TrimSpace(text), err
+}
+
+// reloadDetail fetches issue again if it's open in the detail pane,
+// scrolling to the end to show a new comment if toEnd is set.
+func (t *tui) reloadDetail(issue Content, toEnd bool) error {
+	if t.detail == nil || t.detail.ID != issue.ID {
+		return nil
+	}
+	detail, err := t.client.GetIssueDetail(t.ctx, issue.Repository.NameWithOwner, issue.Number)
+	if err != nil {
+		return err
+	}
+	t.detail = &detail
+	t.drawDetail()
+	if toEnd {
+		t.textbox.ScrollToEnd()
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Config is read from ~/.config/proj/config.yml:
+//
+//	host: github.com
+//	owner: sourcegraph
+//	project: 123
+//	hosts:
+//	  github.example.com:
+//	    token: ghp_...
+//	projects:
+//	  sourcegraph/123:
+//	    user: alice
+//	    v2: true
+//	    status-field: Status
+type Config struct {
+	Host    string
+	Token   string
+	Owner   string
+	Project int
+	// Hosts holds tokens by hostname.
+	Hosts map[string]string
+	// Projects holds per-project preferences keyed by owner/number.
+	Projects map[string]ProjectConfig
+
+	path string
+}
+
+type ProjectConfig struct {
+	User        string
+	V2          *bool
+	StatusField string
+}
+
+func configDir(app string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, app)
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, app)
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", app)
+}
+
+func configPath() string {
+	if p := os.Getenv("PROJ_CONFIG"); p != "" {
+		return p
+	}
+	return filepath.Join(configDir("proj"), "config.yml")
+}
+
+// LoadConfig reads the proj config file. A missing file is not an error.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{path: configPath()}
+	data, err := ioutil.ReadFile(cfg.path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", cfg.path, err)
+	}
+	cfg.Host = yamlString(doc, "host")
+	cfg.Token = yamlString(doc, "token")
+	cfg.Owner = yamlString(doc, "owner")
+	if p := yamlString(doc, "project"); p != "" {
+		cfg.Project, err = strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid project number %q", cfg.path, p)
+		}
+	}
+	cfg.Hosts = make(map[string]string)
+	for host, v := range yamlMap(doc, "hosts") {
+		if m, ok := v.(map[string]interface{}); ok {
+			cfg.Hosts[normalizeHost(host)] = yamlString(m, "token")
+		}
+	}
+	cfg.Projects = make(map[string]ProjectConfig)
+	for key, v := range yamlMap(doc, "projects") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pc := ProjectConfig{
+			User:        yamlString(m, "user"),
+			StatusField: yamlString(m, "status-field"),
+		}
+		if v2 := yamlString(m, "v2"); v2 != "" {
+			b, err := strconv.ParseBool(v2)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid v2 setting for %s: %q", cfg.path, key, v2)
+			}
+			pc.V2 = &b
+		}
+		cfg.Projects[key] = pc
+	}
+	return cfg, nil
+}
+
+func (c *Config) ProjectConfig(ref ProjectRef) ProjectConfig {
+	return c.Projects[ref.String()]
+}
+
+// ResolveToken finds a token for host, trying in order: the explicit token,
+// the environment, the gh CLI's hosts.yml and the proj config file.
+func ResolveToken(explicit, host string, cfg *Config) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	if t := tokenForHost(host); t != "" {
+		return t, nil
+	}
+	if t := ghToken(host); t != "" {
+		return t, nil
+	}
+	if t := cfg.Hosts[normalizeHost(host)]; t != "" {
+		return t, nil
+	}
+	if cfg.Token != "" && normalizeHost(cfg.Host) == normalizeHost(host) {
+		return cfg.Token, nil
+	}
+	envVar := "GH_TOKEN"
+	if isEnterprise(host) {
+		envVar = "GH_ENTERPRISE_TOKEN"
+	}
+	return "", fmt.Errorf("no token found for %s: set %s, run `gh auth login --hostname %s`, or add it to %s",
+		normalizeHost(host), envVar, normalizeHost(host), cfg.path)
+}
+
+// ghToken reads the oauth token the gh CLI stored for host, if any.
+func ghToken(host string) string {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		dir = configDir("gh")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+	doc, err := parseYAML(data)
+	if err != nil {
+		return ""
+	}
+	for h, v := range doc {
+		if m, ok := v.(map[string]interface{}); ok && normalizeHost(h) == normalizeHost(host) {
+			return yamlString(m, "oauth_token")
+		}
+	}
+	return ""
+}
+
+// parseYAML parses the small subset of YAML found in proj and gh config
+// files: nested mappings with scalar values. Sequences are skipped.
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	type frame struct {
+		indent int
+		m      map[string]interface{}
+	}
+	root := make(map[string]interface{})
+	stack := []frame{{indent: -1, m: root}}
+	skipIndent := -1
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content == "---" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		indent := len(line) - len(content)
+		if skipIndent >= 0 {
+			if indent > skipIndent || strings.HasPrefix(content, "- ") || content == "-" {
+				continue
+			}
+			skipIndent = -1
+		}
+		if strings.HasPrefix(content, "- ") || content == "-" {
+			skipIndent = indent
+			continue
+		}
+		for len(stack) > 1 && indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		key, value, ok := splitYAMLLine(content)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		parent := stack[len(stack)-1].m
+		if value == "" {
+			child := make(map[string]interface{})
+			parent[key] = child
+			stack = append(stack, frame{indent: indent, m: child})
+			continue
+		}
+		parent[key] = value
+	}
+	return root, nil
+}
+
+func splitYAMLLine(s string) (string, string, bool) {
+	var key string
+	if s[0] == '"' || s[0] == '\'' {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, s = s[1:end+1], s[end+2:]
+		if !strings.HasPrefix(s, ":") {
+			return "", "", false
+		}
+		s = s[1:]
+	} else {
+		i := strings.Index(s, ": ")
+		switch {
+		case i >= 0:
+			key, s = s[:i], s[i+1:]
+		case strings.HasSuffix(s, ":"):
+			key, s = s[:len(s)-1], ""
+		default:
+			return "", "", false
+		}
+	}
+	return strings.TrimSpace(key), yamlScalar(strings.TrimSpace(s)), true
+}
+
+func yamlScalar(s string) string {
+	if s == "" {
+		return ""
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				if u, err := strconv.Unquote(s[:i+1]); err == nil {
+					return u
+				}
+				break
+			}
+		}
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.Replace(s[1:i], "''", "'", -1)
+		}
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if s == "~" || s == "null" {
+		return ""
+	}
+	return s
+}
+
+func yamlString(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func yamlMap(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "flat",
+			in:   "token: abc\nhost: github.com\n",
+			want: map[string]interface{}{"token": "abc", "host": "github.com"},
+		},
+		{
+			name: "nested",
+			in: `---
+# gh hosts.yml
+github.com:
+    user: alice
+    oauth_token: "gho_123"
+ghe.example.com:
+    oauth_token: 'it''s'
+`,
+			want: map[string]interface{}{
+				"github.com":      map[string]interface{}{"user": "alice", "oauth_token": "gho_123"},
+				"ghe.example.com": map[string]interface{}{"oauth_token": "it's"},
+			},
+		},
+		{
+			name: "dedent",
+			in:   "projects:\n  alice/1:\n    user: bob\n  alice/2:\n    v2: true\ntoken: x\n",
+			want: map[string]interface{}{
+				"projects": map[string]interface{}{
+					"alice/1": map[string]interface{}{"user": "bob"},
+					"alice/2": map[string]interface{}{"v2": "true"},
+				},
+				"token": "x",
+			},
+		},
+		{
+			name: "sequences skipped",
+			in:   "aliases:\n  - one\n  - two\ntoken: x\n",
+			want: map[string]interface{}{"aliases": map[string]interface{}{}, "token": "x"},
+		},
+		{
+			name: "quoted key",
+			in:   "\"a: b\": c\n",
+			want: map[string]interface{}{"a: b": "c"},
+		},
+		{
+			name:    "not a mapping",
+			in:      "token\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseYAML() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseYAML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"plain # comment", "plain"},
+		{"a#b", "a#b"},
+		{`"double \"quoted\""`, `double "quoted"`},
+		{`"tab\t" # comment`, "tab\t"},
+		{`'single ''quoted'''`, `single 'quoted'`},
+		{"~", ""},
+		{"null", ""},
+		{"'~'", "~"},
+	}
+	for _, tt := range tests {
+		if got := yamlScalar(tt.in); got != tt.want {
+			t.Errorf("yamlScalar(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenForHost(t *testing.T) {
+	vars := []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	for _, v := range vars {
+		old, ok := os.LookupEnv(v)
+		defer func(v, old string, ok bool) {
+			if ok {
+				os.Setenv(v, old)
+			} else {
+				os.Unsetenv(v)
+			}
+		}(v, old, ok)
+		os.Unsetenv(v)
+	}
+
+	tests := []struct {
+		env  map[string]string
+		host string
+		want string
+	}{
+		{map[string]string{"GH_TOKEN": "gh", "GITHUB_TOKEN": "github"}, "github.com", "gh"},
+		{map[string]string{"GITHUB_TOKEN": "github"}, "github.com", "github"},
+		{map[string]string{"GH_ENTERPRISE_TOKEN": "gh", "GITHUB_ENTERPRISE_TOKEN": "github"}, "ghe.example.com", "gh"},
+		{map[string]string{"GITHUB_ENTERPRISE_TOKEN": "github"}, "ghe.example.com", "github"},
+		{map[string]string{"GH_TOKEN": "gh"}, "ghe.example.com", ""},
+	}
+	for _, tt := range tests {
+		for _, v := range vars {
+			os.Setenv(v, tt.env[v])
+		}
+		if got := tokenForHost(tt.host); got != tt.want {
+			t.Errorf("tokenForHost(%q) with %v = %q, want %q", tt.host, tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// IssueDetail is an issue or pull request with its body and the latest of
+// its comments and events, as shown in the TUI's detail pane.
+type IssueDetail struct {
+	Content
+	TimelineItems struct {
+		TotalCount int            `json:"totalCount"`
+		Nodes      []TimelineItem `json:"nodes"`
+	} `json:"timelineItems"`
+}
+
+// TimelineItem is a comment, review or event on an issue or pull request.
+// Which fields are set depends on Type, the GraphQL type name.
+type TimelineItem struct {
+	Type            string    `json:"__typename"`
+	ID              string    `json:"id"`
+	CreatedAt       time.Time `json:"createdAt"`
+	Actor           *Author   `json:"actor"`
+	Author          *Author   `json:"author"`
+	Body            string    `json:"body"`
+	URL             string    `json:"url"`
+	ViewerDidAuthor bool      `json:"viewerDidAuthor"`
+	State           string    `json:"state"`
+	Label           *Label    `json:"label"`
+	Assignee        *Author   `json:"assignee"`
+	PreviousTitle   string    `json:"previousTitle"`
+	CurrentTitle    string    `json:"currentTitle"`
+	MilestoneTitle  string    `json:"milestoneTitle"`
+	Source          *struct {
+		URL string `json:"url"`
+	} `json:"source"`
+}
+
+// timelineLimit is how many of the latest timeline items are fetched.
+const timelineLimit = 100
+
+// timelineSelection is shared by the issue and pull request timelines,
+// which are different connection types.
+const timelineSelection = `{
+	totalCount
+	nodes {
+		__typename
+		... on IssueComment {
+			id
+			author {
+				login
+			}
+			body
+			createdAt
+			url
+			viewerDidAuthor
+		}
+		... on PullRequestReview {
+			id
+			author {
+				login
+			}
+			body
+			createdAt
+			url
+			state
+		}
+		... on LabeledEvent {
+			createdAt
+			actor {
+				login
+			}
+			label {
+				name
+				color
+			}
+		}
+		... on UnlabeledEvent {
+			createdAt
+			actor {
+				login
+			}
+			label {
+				name
+				color
+			}
+		}
+		... on AssignedEvent {
+			createdAt
+			actor {
+				login
+			}
+			assignee {
+				... on Actor {
+					login
+				}
+			}
+		}
+		... on UnassignedEvent {
+			createdAt
+			actor {
+				login
+			}
+			assignee {
+				... on Actor {
+					login
+				}
+			}
+		}
+		... on MilestonedEvent {
+			createdAt
+			actor {
+				login
+			}
+			milestoneTitle
+		}
+		... on DemilestonedEvent {
+			createdAt
+			actor {
+				login
+			}
+			milestoneTitle
+		}
+		... on RenamedTitleEvent {
+			createdAt
+			actor {
+				login
+			}
+			previousTitle
+			currentTitle
+		}
+		... on CrossReferencedEvent {
+			createdAt
+			actor {
+				login
+			}
+			source {
+				... on Issue {
+					url
+				}
+				... on PullRequest {
+					url
+				}
+			}
+		}
+		... on ClosedEvent {
+			createdAt
+			actor {
+				login
+			}
+		}
+		... on ReopenedEvent {
+			createdAt
+			actor {
+				login
+			}
+		}
+		... on MergedEvent {
+			createdAt
+			actor {
+				login
+			}
+		}
+	}
+}`
+
+const issueTimelineTypes = `ISSUE_COMMENT, LABELED_EVENT, UNLABELED_EVENT, ASSIGNED_EVENT, UNASSIGNED_EVENT,
+	MILESTONED_EVENT, DEMILESTONED_EVENT, RENAMED_TITLE_EVENT, CROSS_REFERENCED_EVENT, CLOSED_EVENT, REOPENED_EVENT`
+
+// GetIssueDetail fetches an issue or pull request with its body and
+// timeline.
+func (c *Client) GetIssueDetail(ctx context.Context, repo string, number int) (IssueDetail, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return IssueDetail{}, fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	req := c.newRequest(fmt.Sprintf(`query getIssueDetail($owner: String!, $name: String!, $number: Int!) {
+		repository(owner: $owner, name: $name) {
+			issueOrPullRequest(number: $number) {
+				...issueFields
+				...pullRequestFields
+				... on Issue {
+					body
+					timelineItems(last: %[1]d, itemTypes: [%[2]s]) %[3]s
+				}
+				... on PullRequest {
+					body
+					timelineItems(last: %[1]d, itemTypes: [%[2]s, PULL_REQUEST_REVIEW, MERGED_EVENT]) %[3]s
+				}
+			}
+		}
+	}`, timelineLimit, issueTimelineTypes, timelineSelection) + contentFragments)
+	req.Var("owner", parts[0])
+	req.Var("name", parts[1])
+	req.Var("number", number)
+
+	res := struct {
+		Repository *struct {
+			IssueOrPullRequest *IssueDetail `json:"issueOrPullRequest"`
+		} `json:"repository"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return IssueDetail{}, err
+	}
+	if res.Repository == nil || res.Repository.IssueOrPullRequest == nil {
+		return IssueDetail{}, fmt.Errorf("couldn't find %s#%d", repo, number)
+	}
+	return *res.Repository.IssueOrPullRequest, nil
+}
+
+// showDetail opens the issue or pull request on a row in the detail pane.
+// Selecting a column header or note moves on to the next row instead.
+func (t *tui) showDetail(row int) {
+	if row < 0 || row >= len(t.rows) || t.rows[row].card == nil || t.rows[row].card.Content.Number == 0 {
+		if !t.kanban && row+1 < len(t.rows) {
+			t.selectRow(row + 1)
+		}
+		return
+	}
+	content := t.rows[row].card.Content
+	t.setMessage(fmt.Sprintf("loading %s", content.URL))
+	detail, err := t.client.GetIssueDetail(t.ctx, content.Repository.NameWithOwner, content.Number)
+	if err != nil {
+		t.fail("", err)
+		return
+	}
+	t.setMessage("")
+	if t.detail == nil {
+		t.flex.AddItem(t.textbox, 0, 3, true)
+	}
+	t.detail = &detail
+	t.search = ""
+	t.commentID = ""
+	t.drawDetail()
+	t.textbox.ScrollToBeginning()
+	t.app.SetFocus(t.textbox)
+}
+
+func (t *tui) closeDetail() {
+	t.flex.RemoveItem(t.textbox)
+	t.app.SetFocus(t.view())
+	t.detail = nil
+	t.commentID = ""
+}
+
+// drawDetail renders the open issue into the detail pane, marking matches
+// of the search.
+func (t *tui) drawDetail() {
+	d := t.detail
+	m := &markdown{search: t.search}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[::b]%s[::-] [blue]#%d[-]\n", m.text(d.Title), d.Number)
+	fmt.Fprintf(&b, "[gray]%s · %s opened %s · %s[-]\n", cardState(d.Content), m.text(d.Author.Login), formatDate(d.CreatedAt), d.Repository.NameWithOwner)
+	var assignees, labels []string
+	for _, a := range d.Assignees.Edges {
+		assignees = append(assignees, m.text(a.Node.Login))
+	}
+	for _, l := range d.Labels.Nodes {
+		name := m.text(l.Name)
+		if len(l.Color) == 6 {
+			name = "[#" + l.Color + "]" + name + "[-]"
+		}
+		labels = append(labels, name)
+	}
+	if len(assignees) > 0 {
+		fmt.Fprintf(&b, "Assignees: %s\n", strings.Join(assignees, ", "))
+	}
+	if len(labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(labels, ", "))
+	}
+	if d.Milestone != nil {
+		fmt.Fprintf(&b, "Milestone: %s\n", m.text(d.Milestone.Title))
+	}
+	fmt.Fprintf(&b, "[lavender]%s[-]\n\n", tview.Escape(d.URL))
+	if body := strings.TrimSpace(d.Body); body != "" {
+		b.WriteString(m.render(body))
+	} else {
+		b.WriteString("[gray]No description provided.[-]\n")
+	}
+
+	items := d.TimelineItems
+	if n := items.TotalCount - len(items.Nodes); n > 0 {
+		fmt.Fprintf(&b, "\n[gray]%d earlier events not shown, see %s[-]\n", n, d.URL)
+	}
+	for _, item := range items.Nodes {
+		switch item.Type {
+		case "IssueComment", "PullRequestReview":
+			verb := "commented"
+			switch item.State {
+			case "APPROVED":
+				verb = "approved these changes"
+			case "CHANGES_REQUESTED":
+				verb = "requested changes"
+			case "DISMISSED":
+				verb = "reviewed, since dismissed"
+			case "COMMENTED":
+				if strings.TrimSpace(item.Body) == "" {
+					// the review's comments are on the diff, not shown here
+					continue
+				}
+				verb = "reviewed"
+			}
+			fmt.Fprintf(&b, "\n[gray]%s[-]\n", strings.Repeat("─", 40))
+			header := fmt.Sprintf("[::b]%s[::-] [gray]%s %s[-]", m.text(login(item.Author)), verb, formatDate(item.CreatedAt))
+			if item.ID != "" && item.ID == t.commentID {
+				header = `["comment"]` + header + `[""]`
+			}
+			b.WriteString(header + "\n\n")
+			if body := strings.TrimSpace(item.Body); body != "" {
+				b.WriteString(m.render(body))
+			}
+		default:
+			if event := describeEvent(item); event != "" {
+				fmt.Fprintf(&b, "\n[gray]%s %s %s[-]\n", m.text(login(item.Actor)), m.text(event), formatDate(item.CreatedAt))
+			}
+		}
+	}
+	t.searchMatches = m.matches
+	t.textbox.SetText(b.String())
+}
+
+// selectComment moves the comment selection in the detail pane to the next
+// or previous comment, starting from the first or last.
+func (t *tui) selectComment(next bool) {
+	var ids []string
+	for _, item := range t.detail.TimelineItems.Nodes {
+		if item.Type == "IssueComment" {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		t.setMessage("no comments to select")
+		return
+	}
+	i := -1
+	for j, id := range ids {
+		if id == t.commentID {
+			i = j
+		}
+	}
+	switch {
+	case i < 0 && next:
+		i = 0
+	case i < 0:
+		i = len(ids) - 1
+	case next && i+1 < len(ids):
+		i++
+	case !next && i > 0:
+		i--
+	}
+	t.commentID = ids[i]
+	t.drawDetail()
+	t.textbox.Highlight("comment").ScrollToHighlight()
+	t.message.SetText(fmt.Sprintf("[gray]comment %d of %d, :comment --edit or --delete to change it", i+1, len(ids)))
+}
+
+// selectedComment returns the comment selected in the detail pane.
+func (t *tui) selectedComment() (TimelineItem, bool) {
+	if t.detail == nil || t.commentID == "" {
+		return TimelineItem{}, false
+	}
+	for _, item := range t.detail.TimelineItems.Nodes {
+		if item.ID == t.commentID {
+			return item, true
+		}
+	}
+	return TimelineItem{}, false
+}
+
+// describeEvent says what a timeline event did, after the name of the
+// actor.
+func describeEvent(item TimelineItem) string {
+	switch item.Type {
+	case "LabeledEvent", "UnlabeledEvent":
+		if item.Label == nil {
+			return ""
+		}
+		if item.Type == "LabeledEvent" {
+			return "added the " + item.Label.Name + " label"
+		}
+		return "removed the " + item.Label.Name + " label"
+	case "AssignedEvent", "UnassignedEvent":
+		who := login(item.Assignee)
+		if item.Actor != nil && item.Assignee != nil && item.Actor.Login == item.Assignee.Login {
+			who = "themselves"
+		}
+		if item.Type == "AssignedEvent" {
+			return "assigned " + who
+		}
+		return "unassigned " + who
+	case "MilestonedEvent":
+		return "added this to the " + item.MilestoneTitle + " milestone"
+	case "DemilestonedEvent":
+		return "removed this from the " + item.MilestoneTitle + " milestone"
+	case "RenamedTitleEvent":
+		return fmt.Sprintf("changed the title from %q to %q", item.PreviousTitle, item.CurrentTitle)
+	case "CrossReferencedEvent":
+		if item.Source == nil {
+			return ""
+		}
+		return "mentioned this in " + item.Source.URL
+	case "ClosedEvent":
+		return "closed this"
+	case "ReopenedEvent":
+		return "reopened this"
+	case "MergedEvent":
+		return "merged this"
+	}
+	return ""
+}
+
+func login(a *Author) string {
+	if a == nil || a.Login == "" {
+		return "ghost"
+	}
+	return a.Login
+}
+
+// searchDetail highlights the matches of term in the detail pane and
+// scrolls to the first.
+func (t *tui) searchDetail(term string) {
+	if t.detail == nil {
+		return
+	}
+	t.search = term
+	t.searchMatch = 0
+	t.drawDetail()
+	t.textbox.Highlight()
+	switch {
+	case term == "":
+		t.setMessage("")
+	case t.searchMatches == 0:
+		t.message.SetText("[gray]no matches for " + tview.Escape(term))
+	default:
+		t.showMatch()
+	}
+}
+
+// nextMatch moves the highlight to the next match of the search, or the
+// previous one, wrapping around.
+func (t *tui) nextMatch(forward bool) {
+	if t.searchMatches == 0 {
+		return
+	}
+	if forward {
+		t.searchMatch = (t.searchMatch + 1) % t.searchMatches
+	} else {
+		t.searchMatch = (t.searchMatch + t.searchMatches - 1) % t.searchMatches
+	}
+	t.showMatch()
+}
+
+func (t *tui) showMatch() {
+	t.textbox.Highlight(fmt.Sprintf("m%d", t.searchMatch)).ScrollToHighlight()
+	t.message.SetText(fmt.Sprintf("[gray]match %d of %d, n and N to move", t.searchMatch+1, t.searchMatches))
+}
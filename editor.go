@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// editorCommand returns the user's editor, from $VISUAL or $EDITOR.
+func editorCommand() string {
+	if e := os.Getenv("VISUAL"); e != "" {
+		return e
+	}
+	if e := os.Getenv("EDITOR"); e != "" {
+		return e
+	}
+	return "vi"
+}
+
+// editText opens text in the user's editor and returns what was saved.
+func editText(text string) (string, error) {
+	f, err := ioutil.TempFile("", "proj-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	args := strings.Fields(editorCommand())
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v", args[0], err)
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// formField is one line of the front matter of a form edited with
+// editText.
+type formField struct {
+	Key, Value string
+}
+
+// formatForm renders fields as front matter between --- lines, followed by
+// body. help is added as comments at the end of the front matter.
+func formatForm(fields []formField, body, help string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s\n", strings.TrimSpace(f.Key+": "+f.Value))
+	}
+	for _, line := range strings.Split(help, "\n") {
+		if line != "" {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+	}
+	b.WriteString("---\n")
+	b.WriteString(body)
+	return b.String()
+}
+
+// parseForm splits text written by formatForm into its front matter and
+// body.
+func parseForm(text string) (map[string]string, string, error) {
+	text = strings.TrimLeft(text, "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, "", fmt.Errorf("missing front matter, the form should start with ---")
+	}
+	text = text[len("---\n"):]
+	end := strings.Index(text, "\n---")
+	if end < 0 {
+		return nil, "", fmt.Errorf("unterminated front matter, expected a closing ---")
+	}
+	header, body := text[:end+1], text[end+len("\n---"):]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+	doc, err := parseYAML([]byte(header))
+	if err != nil {
+		return nil, "", err
+	}
+	fields := make(map[string]string)
+	for k, v := range doc {
+		if s, ok := v.(string); ok {
+			fields[k] = s
+		}
+	}
+	return fields, strings.TrimSpace(body), nil
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+const issueFormHelp = `The first line after the front matter is the title, the rest is the body.
+Assignees and labels are comma separated. Save an empty title to cancel.`
+
+func issueForm(issue NewIssue) string {
+	body := issue.Title + "\n\n"
+	if issue.Body != "" {
+		body += issue.Body + "\n"
+	}
+	return formatForm([]formField{
+		{"repo", issue.Repo},
+		{"column", issue.Column},
+		{"assignees", strings.Join(issue.Assignees, ", ")},
+		{"labels", strings.Join(issue.Labels, ", ")},
+	}, body, issueFormHelp)
+}
+
+// parseIssueForm reads back an issueForm. An empty title means the user
+// cancelled, and is returned as a zero NewIssue.
+func parseIssueForm(text string) (NewIssue, error) {
+	fields, body, err := parseForm(text)
+	if err != nil {
+		return NewIssue{}, err
+	}
+	title, body := splitNote(body)
+	if title == "" {
+		return NewIssue{}, nil
+	}
+	return NewIssue{
+		Repo:      fields["repo"],
+		Column:    fields["column"],
+		Title:     title,
+		Body:      body,
+		Assignees: splitList(fields["assignees"]),
+		Labels:    splitList(fields["labels"]),
+	}, nil
+}
+
+const editFormHelp = `Edit the title above and the body below the front matter.
+Nothing is saved if neither changes, or if the title is left empty.`
+
+// editForm renders the title and body of an issue for editing. The title is
+// quoted so that colons and # survive the front matter.
+func editForm(issue Content) string {
+	return formatForm([]formField{{"title", strconv.Quote(issue.Title)}}, normalizeBody(issue.Body)+"\n", editFormHelp)
+}
+
+// parseEditForm reads back an editForm.
+func parseEditForm(text string) (string, string, error) {
+	fields, body, err := parseForm(text)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(fields["title"]), body, nil
+}
+
+// normalizeBody trims a body and uses the line endings editors write, as
+// GitHub keeps the \r\n of bodies written in the browser.
+func normalizeBody(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+// diffIssue compares an edited title and body with issue, returning nil for
+// each that didn't change.
+func diffIssue(issue Content, title, body string) (*string, *string) {
+	var newTitle, newBody *string
+	if title != issue.Title {
+		newTitle = &title
+	}
+	if body != normalizeBody(issue.Body) {
+		newBody = &body
+	}
+	return newTitle, newBody
+}
+
+// editConflict reports whether the title or body of an issue changed on
+// GitHub between fetching orig and fresh.
+func editConflict(orig, fresh Content) bool {
+	if !fresh.UpdatedAt.After(orig.UpdatedAt) {
+		return false
+	}
+	return fresh.Title != orig.Title || normalizeBody(fresh.Body) != normalizeBody(orig.Body)
+}
+
+// describeEdit summarises the changes made by diffIssue.
+func describeEdit(issue Content, title, body *string) string {
+	var changes []string
+	if title != nil {
+		changes = append(changes, "title")
+	}
+	if body != nil {
+		added, removed := lineDiff(normalizeBody(issue.Body), *body)
+		changes = append(changes, fmt.Sprintf("body +%d -%d lines", added, removed))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// lineDiff counts the lines added and removed going from a to b.
+func lineDiff(a, b string) (int, int) {
+	x, y := splitLines(a), splitLines(b)
+	// lcs[i][j] is the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	common := lcs[0][0]
+	return len(y) - common, len(x) - common
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseForm(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		wantFields map[string]string
+		wantBody   string
+		wantErr    bool
+	}{
+		{
+			name:       "fields and body",
+			in:         "---\nrepo: o/r\ncolumn: To do\n# help\n---\nTitle\n\nBody\n",
+			wantFields: map[string]string{"repo": "o/r", "column": "To do"},
+			wantBody:   "Title\n\nBody",
+		},
+		{
+			name:       "leading blank lines",
+			in:         "\n\n---\nrepo: o/r\n---\n",
+			wantFields: map[string]string{"repo": "o/r"},
+		},
+		{
+			name:       "empty field",
+			in:         "---\nlabels:\n---\nbody",
+			wantFields: map[string]string{},
+			wantBody:   "body",
+		},
+		{
+			name:       "rule in body",
+			in:         "---\nrepo: o/r\n---\nabove\n---\nbelow",
+			wantFields: map[string]string{"repo": "o/r"},
+			wantBody:   "above\n---\nbelow",
+		},
+		{name: "no front matter", in: "Title\n", wantErr: true},
+		{name: "unterminated", in: "---\nrepo: o/r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, body, err := parseForm(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseForm() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("parseForm() fields = %v, want %v", fields, tt.wantFields)
+			}
+			if body != tt.wantBody {
+				t.Errorf("parseForm() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestEditFormRoundTrip(t *testing.T) {
+	tests := []Content{
+		{Title: "Plain title", Body: "Some body"},
+		{Title: "Colons: and # hashes", Body: "# Heading\n\n- item"},
+		{Title: `"Quoted" title`, Body: ""},
+		{Title: "Windows", Body: "line one\r\nline two\r\n"},
+		{Title: "Rule", Body: "above\n\n---\n\nbelow"},
+	}
+	for _, issue := range tests {
+		title, body, err := parseEditForm(editForm(issue))
+		if err != nil {
+			t.Errorf("parseEditForm(editForm(%q)): %v", issue.Title, err)
+			continue
+		}
+		if title != issue.Title {
+			t.Errorf("title = %q, want %q", title, issue.Title)
+		}
+		if want := normalizeBody(issue.Body); body != want {
+			t.Errorf("body = %q, want %q", body, want)
+		}
+		if newTitle, newBody := diffIssue(issue, title, body); newTitle != nil || newBody != nil {
+			t.Errorf("diffIssue() of unchanged %q = %v, %v, want nil, nil", issue.Title, newTitle, newBody)
+		}
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		a, b                   string
+		wantAdded, wantRemoved int
+	}{
+		{"", "", 0, 0},
+		{"", "one", 1, 0},
+		{"one", "", 0, 1},
+		{"one\ntwo", "one\ntwo", 0, 0},
+		{"one\ntwo", "one\nthree", 1, 1},
+		{"one\ntwo\nthree", "zero\none\nthree", 1, 1},
+		{"a\nb\nc", "c\nb\na", 2, 2},
+		{"a\nb", "a\nb\nb\nc", 2, 0},
+	}
+	for _, tt := range tests {
+		added, removed := lineDiff(tt.a, tt.b)
+		if added != tt.wantAdded || removed != tt.wantRemoved {
+			t.Errorf("lineDiff(%q, %q) = +%d -%d, want +%d -%d", tt.a, tt.b, added, removed, tt.wantAdded, tt.wantRemoved)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const filterHelp = `Filters are space separated terms that must all match:
+
+  assignee:alice,bob   assigned to any of alice or bob (none for unassigned)
+  owner:alice          assignee of an issue or author of a pull request
+  author:carol         opened by carol
+  label:bug            labelled bug (none for unlabelled)
+  milestone:Q4         in milestone Q4 (none for no milestone)
+  type:pr              one of issue, pr or note
+  state:open           one of open, closed or merged
+  column:"In Progress" in the named column
+  updated:>7d          last updated more than 7 days ago; also <, >=, <=, and
+                       dates such as updated:>=2021-01-31
+  "search text"        title or note contains the text
+
+Prefix a term with - to exclude matching cards, e.g. -label:wontfix.`
+
+var filterKeys = map[string]bool{
+	"assignee":  true,
+	"owner":     true,
+	"author":    true,
+	"label":     true,
+	"milestone": true,
+	"type":      true,
+	"state":     true,
+	"column":    true,
+	"updated":   true,
+}
+
+// Filter selects cards using a small search language, see filterHelp.
+type Filter struct {
+	src   string
+	terms []filterTerm
+	// now is used to evaluate relative dates.
+	now time.Time
+}
+
+type filterTerm struct {
+	key    string
+	values []string
+	negate bool
+}
+
+func ParseFilter(s string) (*Filter, error) {
+	f := &Filter{src: strings.TrimSpace(s), now: time.Now()}
+	var (
+		cur     strings.Builder
+		term    filterTerm
+		inQuote bool
+		hasKey  bool
+		started bool
+	)
+	endValue := func() {
+		term.values = append(term.values, cur.String())
+		cur.Reset()
+	}
+	endTerm := func() error {
+		if !started {
+			return nil
+		}
+		endValue()
+		if !hasKey && term.values[0] == "" {
+			term, started = filterTerm{}, false
+			return nil
+		}
+		if hasKey {
+			term.key = strings.ToLower(term.key)
+			if !filterKeys[term.key] {
+				return fmt.Errorf("unknown filter %q", term.key)
+			}
+			for _, v := range term.values {
+				if v == "" {
+					return fmt.Errorf("missing value for %s:", term.key)
+				}
+			}
+			if term.key == "updated" {
+				for _, v := range term.values {
+					if _, _, err := parseDateFilter(v, f.now); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		f.terms = append(f.terms, term)
+		term, hasKey, started = filterTerm{}, false, false
+		return nil
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			started = true
+		case inQuote:
+			cur.WriteRune(r)
+		case unicode.IsSpace(r):
+			if err := endTerm(); err != nil {
+				return nil, err
+			}
+		case r == '-' && !started:
+			term.negate = !term.negate
+			started = true
+		case r == ':' && !hasKey && cur.Len() > 0:
+			term.key = cur.String()
+			cur.Reset()
+			hasKey = true
+		case r == ',' && hasKey:
+			endValue()
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in filter")
+	}
+	if err := endTerm(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.terms) == 0
+}
+
+// Match reports whether card in col matches every term of the filter.
+func (f *Filter) Match(col ColumnNode, card Node) bool {
+	if f == nil {
+		return true
+	}
+	for _, t := range f.terms {
+		matched := false
+		for _, v := range t.values {
+			if f.matchValue(t.key, v, col, card) {
+				matched = true
+				break
+			}
+		}
+		if matched == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) matchValue(key, v string, col ColumnNode, card Node) bool {
+	c := card.Content
+	isNote := c.Number == 0
+	switch key {
+	case "":
+		v = strings.ToLower(v)
+		return strings.Contains(strings.ToLower(c.Title), v) || strings.Contains(strings.ToLower(card.Note), v)
+	case "assignee":
+		if strings.EqualFold(v, "none") {
+			return !isNote && len(c.Assignees.Edges) == 0
+		}
+		for _, a := range c.Assignees.Edges {
+			if strings.EqualFold(a.Node.Login, v) {
+				return true
+			}
+		}
+		return false
+	case "owner":
+		return !isNote && strings.EqualFold(getOwner(c), v)
+	case "author":
+		return !isNote && strings.EqualFold(c.Author.Login, v)
+	case "label":
+		if strings.EqualFold(v, "none") {
+			return !isNote && len(c.Labels.Nodes) == 0
+		}
+		for _, l := range c.Labels.Nodes {
+			if strings.EqualFold(l.Name, v) {
+				return true
+			}
+		}
+		return false
+	case "milestone":
+		if strings.EqualFold(v, "none") {
+			return !isNote && c.Milestone == nil
+		}
+		return c.Milestone != nil && strings.EqualFold(c.Milestone.Title, v)
+	case "type":
+		switch strings.ToLower(v) {
+		case "note", "draft":
+			return isNote
+		case "pr", "pull", "pullrequest":
+			return !isNote && strings.Contains(c.URL, "pull")
+		case "issue":
+			return !isNote && !strings.Contains(c.URL, "pull")
+		}
+		return false
+	case "state":
+		return !isNote && strings.EqualFold(c.State, v)
+	case "column":
+		return matchColumn(col.Name, v)
+	case "updated":
+		if isNote || c.UpdatedAt.IsZero() {
+			return false
+		}
+		op, t, err := parseDateFilter(v, f.now)
+		if err != nil {
+			return false
+		}
+		return compareTime(c.UpdatedAt, op, t)
+	}
+	return false
+}
+
+// parseDateFilter parses a comparison such as >7d or <=2021-01-31. Relative
+// durations are ages, so >7d is turned into a comparison against the time
+// 7 days ago that matches anything older.
+func parseDateFilter(v string, now time.Time) (string, time.Time, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(v, prefix) {
+			op, v = prefix, v[len(prefix):]
+			break
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		if op == "" {
+			op = "="
+		}
+		return op, t, nil
+	}
+	if len(v) < 2 {
+		return "", time.Time{}, fmt.Errorf("invalid date %q", v)
+	}
+	n, err := strconv.Atoi(v[:len(v)-1])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid date %q", v)
+	}
+	var unit time.Duration
+	switch v[len(v)-1] {
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return "", time.Time{}, fmt.Errorf("invalid date %q, expected a number of h, d or w", v)
+	}
+	t := now.Add(-time.Duration(n) * unit)
+	// an age greater than n is a time before now-n
+	switch op {
+	case ">":
+		op = "<"
+	case ">=":
+		op = "<="
+	case "<":
+		op = ">"
+	case "<=":
+		op = ">="
+	case "", "=":
+		op = ">="
+	}
+	return op, t, nil
+}
+
+func compareTime(a time.Time, op string, b time.Time) bool {
+	switch op {
+	case ">":
+		return a.After(b)
+	case ">=":
+		return !a.Before(b)
+	case "<":
+		return a.Before(b)
+	case "<=":
+		return !a.After(b)
+	case "=":
+		y1, m1, d1 := a.Local().Date()
+		y2, m2, d2 := b.Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	}
+	return false
+}
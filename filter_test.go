@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"", false},
+		{`label:bug -assignee:none "some text"`, false},
+		{`column:"In Progress",Done`, false},
+		{"updated:>7d updated:<=2021-01-31", false},
+		{"colour:red", true},
+		{"label:", true},
+		{"label:bug,", true},
+		{"updated:>7y", true},
+		{"updated:soon", true},
+		{`"unterminated`, true},
+	}
+	for _, tt := range tests {
+		_, err := ParseFilter(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFilter(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, time.Local)
+	issue := Node{Content: Content{
+		Number:    1,
+		Title:     "Crash on start",
+		URL:       "https://github.com/o/r/issues/1",
+		State:     "OPEN",
+		Author:    Author{Login: "carol"},
+		UpdatedAt: now.Add(-10 * 24 * time.Hour),
+		Labels:    Labels{Nodes: []Label{{Name: "bug"}, {Name: "good first issue"}}},
+	}}
+	pr := Node{Content: Content{
+		Number:    2,
+		Title:     "Fix crash",
+		URL:       "https://github.com/o/r/pull/2",
+		State:     "MERGED",
+		UpdatedAt: now.Add(-2 * time.Hour),
+		Milestone: &Milestone{Title: "Q1"},
+	}}
+	note := Node{Note: "remember the release notes"}
+	col := ColumnNode{Name: "In Progress"}
+
+	tests := []struct {
+		filter string
+		card   Node
+		want   bool
+	}{
+		{"", issue, true},
+		{"crash", issue, true},
+		{"crash", note, false},
+		{`"release notes"`, note, true},
+		{"label:BUG", issue, true},
+		{`label:"good first issue"`, issue, true},
+		{"label:none", pr, true},
+		{"label:none", note, false},
+		{"-label:bug", issue, false},
+		{"label:docs,bug", issue, true},
+		{"assignee:none", issue, true},
+		{"author:carol", issue, true},
+		{"milestone:q1", pr, true},
+		{"milestone:none", issue, true},
+		{"type:pr", pr, true},
+		{"type:issue", pr, false},
+		{"type:note", note, true},
+		{"state:merged", pr, true},
+		{`column:"in progress"`, note, true},
+		{"column:done", note, false},
+		{"updated:>7d", issue, true},
+		{"updated:>7d", pr, false},
+		{"updated:<1d", pr, true},
+		{"updated:<=3h", pr, true},
+		{"updated:>=1w", issue, true},
+		{"updated:2021-02-28", issue, true},
+		{"updated:>2021-03-01", issue, false},
+		{"updated:<1w", note, false},
+		{"label:bug type:issue -state:closed", issue, true},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", tt.filter, err)
+		}
+		f.now = now
+		if got := f.Match(col, tt.card); got != tt.want {
+			t.Errorf("filter %q matching %q = %v, want %v", tt.filter, tt.card.Content.Title+tt.card.Note, got, tt.want)
+		}
+	}
+}
@@ -5,11 +5,13 @@ go 1.15
 require (
 	github.com/apoorvam/goterminal v0.0.0-20180523175556-614d345c47e5 // indirect
 	github.com/fatih/color v1.10.0
-	github.com/gdamore/tcell/v2 v2.1.0 // indirect
+	github.com/gdamore/tcell/v2 v2.1.0
 	github.com/kenshaw/emoji v0.1.0 // indirect
 	github.com/machinebox/graphql v0.2.2
 	github.com/marcusolsson/tui-go v0.4.0 // indirect
-	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/mattn/go-isatty v0.0.12
+	github.com/mattn/go-runewidth v0.0.10
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rivo/tview v0.0.0-20210217110421-8a8f78a6dd01
+	golang.org/x/sys v0.0.0-20210113181707-4bcb84eeeb78
 )
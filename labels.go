@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GetLabels returns the labels of a repository, sorted by name.
+func (c *Client) GetLabels(ctx context.Context, repo string) ([]Label, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	var labels []Label
+	cursor := ""
+	for {
+		req := c.newRequest(`query getLabels($owner: String!, $name: String!, $cursor: String) {
+			repository(owner: $owner, name: $name) {
+				labels(first: 100, after: $cursor, orderBy: {field: NAME, direction: ASC}) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+					nodes {
+						id
+						name
+						color
+					}
+				}
+			}
+		}`)
+		req.Var("owner", parts[0])
+		req.Var("name", parts[1])
+		req.Var("cursor", nullable(cursor))
+
+		res := struct {
+			Repository *struct {
+				Labels struct {
+					PageInfo PageInfo `json:"pageInfo"`
+					Nodes    []Label  `json:"nodes"`
+				} `json:"labels"`
+			} `json:"repository"`
+		}{}
+		if err := c.query(ctx, req, &res); err != nil {
+			return nil, err
+		}
+		if res.Repository == nil {
+			return nil, fmt.Errorf("couldn't find repository %s", repo)
+		}
+		labels = append(labels, res.Repository.Labels.Nodes...)
+		if !res.Repository.Labels.PageInfo.HasNextPage {
+			return labels, nil
+		}
+		cursor = res.Repository.Labels.PageInfo.EndCursor
+	}
+}
+
+// GetMilestones returns the open milestones of a repository, the soonest
+// due first.
+func (c *Client) GetMilestones(ctx context.Context, repo string) ([]Milestone, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	var milestones []Milestone
+	cursor := ""
+	for {
+		req := c.newRequest(`query getMilestones($owner: String!, $name: String!, $cursor: String) {
+			repository(owner: $owner, name: $name) {
+				milestones(first: 100, after: $cursor, states: OPEN, orderBy: {field: DUE_DATE, direction: ASC}) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+					nodes {
+						id
+						title
+					}
+				}
+			}
+		}`)
+		req.Var("owner", parts[0])
+		req.Var("name", parts[1])
+		req.Var("cursor", nullable(cursor))
+
+		res := struct {
+			Repository *struct {
+				Milestones struct {
+					PageInfo PageInfo    `json:"pageInfo"`
+					Nodes    []Milestone `json:"nodes"`
+				} `json:"milestones"`
+			} `json:"repository"`
+		}{}
+		if err := c.query(ctx, req, &res); err != nil {
+			return nil, err
+		}
+		if res.Repository == nil {
+			return nil, fmt.Errorf("couldn't find repository %s", repo)
+		}
+		milestones = append(milestones, res.Repository.Milestones.Nodes...)
+		if !res.Repository.Milestones.PageInfo.HasNextPage {
+			return milestones, nil
+		}
+		cursor = res.Repository.Milestones.PageInfo.EndCursor
+	}
+}
+
+// findLabels looks up names among the labels of repo, ignoring case as
+// GitHub does.
+func findLabels(labels []Label, names []string, repo string) ([]Label, error) {
+	var found []Label
+	for _, name := range names {
+		i := 0
+		for i < len(labels) && !strings.EqualFold(labels[i].Name, name) {
+			i++
+		}
+		if i == len(labels) {
+			return nil, fmt.Errorf("no label %q in %s%s", name, repo, suggest(name, labelNames(labels)))
+		}
+		found = append(found, labels[i])
+	}
+	return found, nil
+}
+
+func labelIDs(labels []Label) []string {
+	ids := make([]string, len(labels))
+	for i, l := range labels {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+func labelNames(labels []Label) []string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// applyLabels returns labels with add and remove applied, sorted by name.
+func applyLabels(labels, add, remove []Label) []Label {
+	var result []Label
+	has := make(map[string]bool)
+	for _, l := range append(append([]Label(nil), labels...), add...) {
+		key := strings.ToLower(l.Name)
+		if has[key] {
+			continue
+		}
+		has[key] = true
+		result = append(result, Label{Name: l.Name, Color: l.Color})
+	}
+	for _, r := range remove {
+		for i := range result {
+			if strings.EqualFold(result[i].Name, r.Name) {
+				result = append(result[:i], result[i+1:]...)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+	return result
+}
+
+// suggest lists the choices that could have been meant by name, for the end
+// of an error message.
+func suggest(name string, choices []string) string {
+	var matches []string
+	for _, c := range choices {
+		if strings.Contains(strings.ToLower(c), strings.ToLower(name)) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		matches = choices
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	if len(matches) > maxCompletions {
+		matches = append(matches[:maxCompletions], "...")
+	}
+	return ", try one of: " + strings.Join(matches, ", ")
+}
+
+// parseLabelArgs splits arguments like +bug and -triage into the labels to
+// add and to remove. Labels without a sign are added.
+func parseLabelArgs(args []string) (add, remove []string) {
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			remove = append(remove, arg[1:])
+		case strings.HasPrefix(arg, "+"):
+			add = append(add, arg[1:])
+		default:
+			add = append(add, arg)
+		}
+	}
+	return add, remove
+}
+
+// splitQuoted splits s on spaces, keeping text in double quotes together.
+func splitQuoted(s string) []string {
+	var (
+		words   []string
+		cur     strings.Builder
+		inQuote bool
+		started bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			started = true
+		case unicode.IsSpace(r) && !inQuote:
+			if started {
+				words = append(words, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		words = append(words, cur.String())
+	}
+	return words
+}
+
+// quoteWord quotes s for splitQuoted if it has spaces.
+func quoteWord(s string) string {
+	if strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// repoOptions are the labels and milestones of a repository, fetched for
+// completion.
+type repoOptions struct {
+	labels     []Label
+	milestones []Milestone
+}
+
+// maxCompletions is how many completions are offered at once.
+const maxCompletions = 10
+
+// complete offers the labels or milestones of the selected card's
+// repository to complete the last word of :label and :milestone. They are
+// fetched in the background the first time they're needed.
+func (t *tui) complete(text string) []string {
+	var cmd string
+	switch {
+	case strings.HasPrefix(text, ":label "):
+		cmd = ":label"
+	case strings.HasPrefix(text, ":milestone "):
+		cmd = ":milestone"
+	default:
+		return nil
+	}
+	repo := t.ref.Repo()
+	if row := t.selectedRow(); row >= 0 && t.rows[row].card != nil && t.rows[row].card.Content.Repository.NameWithOwner != "" {
+		repo = t.rows[row].card.Content.Repository.NameWithOwner
+	}
+	if t.detail != nil {
+		repo = t.detail.Repository.NameWithOwner
+	}
+	if repo == "" {
+		return nil
+	}
+	opts, ok := t.repoOptions[repo]
+	if !ok {
+		t.fetchOptions(repo)
+		return nil
+	}
+
+	// a milestone is the whole argument, a label the word being typed,
+	// which may be in an unterminated quote
+	var prefix, word string
+	var names []string
+	if cmd == ":milestone" {
+		prefix = ":milestone "
+		word = strings.Trim(text[len(prefix):], `"`)
+		for _, m := range opts.milestones {
+			names = append(names, m.Title)
+		}
+	} else {
+		start := strings.LastIndexByte(text, ' ') + 1
+		if strings.Count(text, `"`)%2 == 1 {
+			start = strings.LastIndexByte(text, '"')
+		}
+		prefix, word = text[:start], strings.Trim(text[start:], `"`)
+		if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "-") {
+			prefix, word = prefix+word[:1], word[1:]
+		}
+		names = labelNames(opts.labels)
+	}
+	var entries []string
+	for _, name := range names {
+		if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(word)) || name == word {
+			continue
+		}
+		entries = append(entries, prefix+quoteWord(name))
+		if len(entries) == maxCompletions {
+			break
+		}
+	}
+	return entries
+}
+
+// fetchOptions loads the labels and milestones of repo for completion
+// without blocking the UI.
+func (t *tui) fetchOptions(repo string) {
+	if t.fetchingOptions[repo] {
+		return
+	}
+	t.fetchingOptions[repo] = true
+	go func() {
+		labels, err := t.client.GetLabels(t.ctx, repo)
+		var milestones []Milestone
+		if err == nil {
+			milestones, err = t.client.GetMilestones(t.ctx, repo)
+		}
+		t.app.QueueUpdateDraw(func() {
+			delete(t.fetchingOptions, repo)
+			if err != nil {
+				t.log.Printf("completing %s: %v", repo, err)
+				return
+			}
+			t.repoOptions[repo] = repoOptions{labels, milestones}
+			t.inputField.Autocomplete()
+		})
+	}()
+}
+
+// labelCommand runs :label, adding the labels given as name or +name and
+// removing those given as -name.
+func (t *tui) labelCommand(args []string) error {
+	add, remove := parseLabelArgs(splitQuoted(strings.Join(args, " ")))
+	if len(add)+len(remove) == 0 {
+		return fmt.Errorf(`usage: :label +<name> -<name>..., quoting names with spaces as "good first issue"`)
+	}
+	issues := issuesOf(t.targets(nil), false)
+	if len(issues) == 0 {
+		t.setMessage("select an issue or pull request to label")
+		return nil
+	}
+	t.setMessage(fmt.Sprintf("labelling %s", describeIssues(issues)))
+	updated, err := t.client.LabelIssues(t.ctx, issues, add, remove)
+	if err != nil {
+		return err
+	}
+	t.clearMarks()
+	t.showField("labels")
+	t.updateIssues(updated)
+	t.setMessage(fmt.Sprintf("labelled %s", describeIssues(issues)))
+	return nil
+}
+
+// milestoneCommand runs :milestone, putting the selected issues in a
+// milestone, or taking them out of theirs with --clear.
+func (t *tui) milestoneCommand(args []string) error {
+	title := strings.Join(splitQuoted(strings.Join(args, " ")), " ")
+	if title == "" {
+		return fmt.Errorf("usage: :milestone <title> | --clear")
+	}
+	if title == "--clear" {
+		title = ""
+	}
+	issues := issuesOf(t.targets(nil), false)
+	if len(issues) == 0 {
+		t.setMessage("select an issue or pull request first")
+		return nil
+	}
+	t.setMessage(fmt.Sprintf("setting the milestone of %s", describeIssues(issues)))
+	updated, err := t.client.SetMilestone(t.ctx, issues, title)
+	if err != nil {
+		return err
+	}
+	t.clearMarks()
+	t.showField("milestone")
+	t.updateIssues(updated)
+	t.setMessage(fmt.Sprintf("updated %s", describeIssues(issues)))
+	return nil
+}
+
+// showField adds an optional field to the cards if it isn't shown yet, so
+// the result of a command is visible.
+func (t *tui) showField(field string) {
+	for _, f := range t.fields {
+		if f == field {
+			return
+		}
+	}
+	t.fields = append(t.fields, field)
+}
+
+// updateIssues replaces the issues on the board with the updated copies and
+// redraws, so changes show without waiting for a refresh.
+func (t *tui) updateIssues(issues []Content) {
+	byID := make(map[string]Content)
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+	for _, col := range t.res.Owner.Project.Columns.Nodes {
+		for i := range col.Cards.Nodes {
+			if issue, ok := byID[col.Cards.Nodes[i].Content.ID]; ok {
+				col.Cards.Nodes[i].Content = issue
+			}
+		}
+	}
+	if t.detail != nil {
+		if issue, ok := byID[t.detail.ID]; ok {
+			t.detail.Labels = issue.Labels
+			t.detail.Milestone = issue.Milestone
+			t.drawDetail()
+		}
+	}
+	t.render()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestSplitQuoted(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"+bug -triage", []string{"+bug", "-triage"}},
+		{`+"good first issue"  -wontfix`, []string{"+good first issue", "-wontfix"}},
+		{`"v1.0 release"`, []string{"v1.0 release"}},
+		{`""`, []string{""}},
+		{`+"unterminated quote`, []string{"+unterminated quote"}},
+	}
+	for _, tt := range tests {
+		if got := splitQuoted(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitQuoted(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelArgs(t *testing.T) {
+	add, remove := parseLabelArgs([]string{"+bug", "-triage", "docs", "-good first issue"})
+	if want := []string{"bug", "docs"}; !reflect.DeepEqual(add, want) {
+		t.Errorf("add = %q, want %q", add, want)
+	}
+	if want := []string{"triage", "good first issue"}; !reflect.DeepEqual(remove, want) {
+		t.Errorf("remove = %q, want %q", remove, want)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tu := &tui{
+		table:  tview.NewTable(),
+		detail: &IssueDetail{Content: Content{Repository: Repository{NameWithOwner: "o/r"}}},
+		repoOptions: map[string]repoOptions{
+			"o/r": {
+				labels:     []Label{{Name: "bug"}, {Name: "build"}, {Name: "good first issue"}, {Name: "docs"}},
+				milestones: []Milestone{{Title: "v1.0"}, {Title: "v1.1 beta"}},
+			},
+		},
+	}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{":close b", nil},
+		{":label b", []string{":label bug", ":label build"}},
+		{":label +B", []string{":label +bug", ":label +build"}},
+		{":label +bug -g", []string{`:label +bug -"good first issue"`}},
+		{`:label +"good f`, []string{`:label +"good first issue"`}},
+		{":label bug", nil},
+		{":label x", nil},
+		{":milestone v1", []string{":milestone v1.0", `:milestone "v1.1 beta"`}},
+		{`:milestone "v1.1`, []string{`:milestone "v1.1 beta"`}},
+	}
+	for _, tt := range tests {
+		if got := tu.complete(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
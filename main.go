@@ -7,45 +7,107 @@ import (
 	"log"
 	"os"
 	"strings"
-	"text/tabwriter"
 
-	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
 )
 
 func main() {
-	projectNumber := flag.Int("p", 0, "project number")
-	user := flag.String("u", "", "filter by user")
-	interactive := flag.Bool("i", false, "interactive mode")
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defaultHostname := os.Getenv("GH_HOST")
+	if defaultHostname == "" {
+		defaultHostname = cfg.Host
+	}
+
+	var owner, hostname string
+	projectNumber := flag.Int("p", cfg.Project, "project number")
+	flag.StringVar(&owner, "o", cfg.Owner, "project owner: organization, user or owner/repo")
+	flag.StringVar(&owner, "owner", cfg.Owner, "project owner: organization, user or owner/repo")
+	flag.StringVar(&hostname, "hostname", defaultHostname, "GitHub hostname, for GitHub Enterprise Server")
+	token := flag.String("token", "", "GitHub token, instead of the environment, gh CLI or config file")
+	interactive := flag.Bool("i", false, "interactive mode, the same as the tui command")
+	colorMode := flag.String("color", "auto", "use color: auto, always or never")
+	v2 := flag.Bool("v2", false, "use the Projects (v2) backend, detected automatically if unset")
+	statusField := flag.String("status-field", defaultStatusField, "single select field used as columns on v2 projects")
+	list := listOptions{format: "table"}
+	list.register(flag.CommandLine)
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: %s [flags] [command] [args]\n\n", os.Args[0])
+		printCommands(out)
+		fmt.Fprintln(out, "\nFlags:")
+		flag.PrintDefaults()
+		fmt.Fprintf(out, "\n%s\n", filterHelp)
+	}
 	flag.Parse()
 
-	ctx := context.Background()
-	if !*interactive {
-		res, err := GetProject(ctx, *projectNumber)
-		if err != nil {
-			log.Fatal(err)
+	cmd, _ := lookupCommand("list")
+	if *interactive {
+		cmd, _ = lookupCommand("tui")
+	}
+	args := flag.Args()
+	if len(args) > 0 {
+		var ok bool
+		cmd, ok = lookupCommand(args[0])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+			flag.Usage()
+			os.Exit(2)
 		}
-		w := tabwriter.NewWriter(os.Stdout, 0, 2, 1, ' ', 0)
-		for _, col := range res.Organization.Project.Columns.Nodes {
-			fmt.Fprintf(w, "%s\t%s\t\t\n", color.GreenString(" "), color.GreenString(col.Name))
-			for _, card := range col.Cards.Nodes {
-				if *user != "" && getOwner(card.Content) != *user {
-					continue
-				}
-				if card.Note != "" {
-					fmt.Fprintf(w, "%s\t%s\t%s\t\n", color.GreenString(" "), color.GreenString(" "), capStr(card.Note, 60))
-					continue
-				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-					color.BlueString(fmt.Sprintf("%v", card.Content.Number)),
-					color.MagentaString(getOwner(card.Content)),
-					capStr(card.Content.Title, 60),
-					color.CyanString(card.Content.URL))
+		args = args[1:]
+	}
+
+	if err := setColorMode(*colorMode); err != nil {
+		log.Fatal(err)
+	}
+	if owner == "" {
+		log.Fatal("no owner: set -o or owner in config")
+	}
+
+	ctx := context.Background()
+	tok, err := ResolveToken(*token, hostname, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := NewClient(tok, WithHost(hostname))
+	ownerType, err := client.DetectOwnerType(ctx, owner)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ref := ProjectRef{Owner: owner, OwnerType: ownerType, Number: *projectNumber, V2: *v2, StatusField: *statusField}
+	prefs := cfg.ProjectConfig(ref)
+	if !isFlagSet("u") && prefs.User != "" {
+		list.user = prefs.User
+	}
+	if !isFlagSet("status-field") && prefs.StatusField != "" {
+		ref.StatusField = prefs.StatusField
+	}
+	if !isFlagSet("v2") {
+		if prefs.V2 != nil {
+			ref.V2 = *prefs.V2
+		} else {
+			ref.V2, err = client.DetectProjectV2(ctx, ref)
+			if err != nil {
+				log.Fatal(err)
 			}
 		}
-		w.Flush()
-	} else {
-		doTUI(ctx, *projectNumber)
 	}
+	e := &env{client: client, ref: ref, list: list}
+	if err := cmd.run(ctx, e, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
 func getOwner(c Content) string {
@@ -58,9 +120,11 @@ func getOwner(c Content) string {
 	return c.Assignees.Edges[0].Node.Login
 }
 
+// capStr cuts s to at most max terminal cells wide, marking it with "..."
+// if it was cut. A max of 0 or less leaves s as it is.
 func capStr(s string, max int) string {
-	if len(s) < max {
+	if max <= 0 || runewidth.StringWidth(s) <= max {
 		return s
 	}
-	return s[:max] + "..."
+	return runewidth.Truncate(s, max, "") + "..."
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	listPattern    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	inlinePattern  = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|!?\\[[^\\]]*\\]\\([^)\\s]+\\)")
+	linkPattern    = regexp.MustCompile(`^!?\[([^\]]*)\]\(([^)\s]+)\)$`)
+)
+
+// markdown renders GitHub flavored markdown with tview color tags for the
+// detail pane. Only what reads badly as plain text is styled: headings,
+// lists, quotes, code and links. Matches of search are wrapped in regions
+// numbered from "m0", and counted in matches.
+type markdown struct {
+	search  string
+	matches int
+}
+
+func (m *markdown) render(text string) string {
+	var b strings.Builder
+	fence := ""
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				continue
+			}
+			b.WriteString("  [yellow]" + m.text(line) + "[-]\n")
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			b.WriteString("[green::b]" + m.text(match[2]) + "[-::-]\n")
+			continue
+		}
+		if rulePattern.MatchString(line) {
+			b.WriteString("[gray]" + strings.Repeat("─", 40) + "[-]\n")
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			quote := strings.TrimPrefix(strings.TrimPrefix(trimmed, ">"), " ")
+			b.WriteString("[gray]│ " + m.text(quote) + "[-]\n")
+			continue
+		}
+		if match := listPattern.FindStringSubmatch(line); match != nil {
+			bullet := match[2]
+			if len(bullet) == 1 && strings.Contains("-*+", bullet) {
+				bullet = "•"
+			}
+			item := match[3]
+			switch {
+			case strings.HasPrefix(item, "[ ] "):
+				bullet, item = bullet+" ☐", item[4:]
+			case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+				bullet, item = bullet+" ☑", item[4:]
+			}
+			b.WriteString(match[1] + "[gray]" + bullet + "[-] " + m.inline(item) + "\n")
+			continue
+		}
+		b.WriteString(m.inline(line) + "\n")
+	}
+	return b.String()
+}
+
+// inline styles code spans, bold text and links within a line.
+func (m *markdown) inline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range inlinePattern.FindAllStringIndex(line, -1) {
+		b.WriteString(m.text(line[last:loc[0]]))
+		last = loc[1]
+		token := line[loc[0]:loc[1]]
+		switch {
+		case strings.HasPrefix(token, "`"):
+			b.WriteString("[yellow]" + m.text(token[1:len(token)-1]) + "[-]")
+		case strings.HasPrefix(token, "**"), strings.HasPrefix(token, "__"):
+			b.WriteString("[::b]" + m.text(token[2:len(token)-2]) + "[::-]")
+		default:
+			link := linkPattern.FindStringSubmatch(token)
+			if link[1] != "" {
+				b.WriteString(m.text(link[1]) + " ")
+			}
+			b.WriteString("[blue::u]" + m.text(link[2]) + "[-::-]")
+		}
+	}
+	b.WriteString(m.text(line[last:]))
+	return b.String()
+}
+
+// text escapes plain text, marking the matches of the search.
+func (m *markdown) text(s string) string {
+	if m.search == "" {
+		return tview.Escape(s)
+	}
+	var b strings.Builder
+	lower, term := strings.ToLower(s), strings.ToLower(m.search)
+	if len(lower) != len(s) || len(term) != len(m.search) {
+		// lowering changed the byte lengths, so match case
+		lower, term = s, m.search
+	}
+	for {
+		i := strings.Index(lower, term)
+		if i < 0 {
+			break
+		}
+		fmt.Fprintf(&b, `%s["m%d"]%s[""]`, tview.Escape(s[:i]), m.matches, tview.Escape(s[i:i+len(term)]))
+		m.matches++
+		s, lower = s[i+len(term):], lower[i+len(term):]
+	}
+	b.WriteString(tview.Escape(s))
+	return b.String()
+}
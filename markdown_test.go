@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestMarkdownRender(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "just text", "just text\n"},
+		{"escapes tags", "a [red] word", "a [red[] word\n"},
+		{"heading", "## Steps ##", "[green::b]Steps[-::-]\n"},
+		{"not a heading", "#123 is fixed", "#123 is fixed\n"},
+		{"bullet", "- one\n  * two", "[gray]•[-] one\n  [gray]•[-] two\n"},
+		{"numbered", "1. first", "[gray]1.[-] first\n"},
+		{"tasks", "- [ ] todo\n- [x] done", "[gray]• ☐[-] todo\n[gray]• ☑[-] done\n"},
+		{"quote", "> quoted", "[gray]│ quoted[-]\n"},
+		{"rule", "***", "[gray]" + "────────────────────────────────────────" + "[-]\n"},
+		{"fence", "```go\nx := [a]\n```\nafter", "  [yellow]x := [a[][-]\nafter\n"},
+		{"code span", "run `make` now", "run [yellow]make[-] now\n"},
+		{"bold", "**very** __much__", "[::b]very[::-] [::b]much[::-]\n"},
+		{"link", "see [docs](https://x.dev)", "see docs [blue::u]https://x.dev[-::-]\n"},
+		{"bare link text", "[](https://x.dev)", "[blue::u]https://x.dev[-::-]\n"},
+		{"crlf", "a\r\nb", "a\nb\n"},
+	}
+	for _, tt := range tests {
+		m := &markdown{}
+		if got := m.render(tt.in); got != tt.want {
+			t.Errorf("%s: render(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownSearch(t *testing.T) {
+	m := &markdown{search: "Fix"}
+	got := m.render("fix the `fix` in **FIX**")
+	want := `["m0"]fix[""] the [yellow]["m1"]fix[""][-] in [::b]["m2"]FIX[""][::-]` + "\n"
+	if got != want {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+	if m.matches != 3 {
+		t.Errorf("matches = %d, want 3", m.matches)
+	}
+}
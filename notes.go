@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AddNote adds a note to the named column. On ProjectV2 boards notes are
+// draft issues, with the first line of text as the title and the rest as
+// the body.
+func (c *Client) AddNote(ctx context.Context, ref ProjectRef, colName, text string) error {
+	proj, err := c.GetProject(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if ref.V2 {
+		return c.addDraftIssue(ctx, proj.Owner.Project, colName, text)
+	}
+	var colID string
+	for _, col := range proj.Owner.Project.Columns.Nodes {
+		if matchColumn(col.Name, colName) {
+			colID = col.ID
+		}
+	}
+	if colID == "" {
+		return fmt.Errorf("couldn't add note: no column %q", colName)
+	}
+	req := c.newRequest(`mutation addNote($colid: ID!, $note: String!) {
+		addProjectCard(input: {clientMutationId: "proj", projectColumnId: $colid, note: $note}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("colid", colID)
+	req.Var("note", text)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+func (c *Client) addDraftIssue(ctx context.Context, proj Project, colName, text string) error {
+	title, body := splitNote(text)
+	req := c.newRequest(`mutation addDraftIssue($projectid: ID!, $title: String!, $body: String) {
+		addProjectV2DraftIssue(input: {clientMutationId: "proj", projectId: $projectid, title: $title, body: $body}) {
+			projectItem {
+				id
+			}
+		}
+	}`)
+	req.Var("projectid", proj.ID)
+	req.Var("title", title)
+	req.Var("body", body)
+
+	res := struct {
+		AddProjectV2DraftIssue struct {
+			ProjectItem struct {
+				ID string `json:"id"`
+			} `json:"projectItem"`
+		} `json:"addProjectV2DraftIssue"`
+	}{}
+	if err := c.mutate(ctx, req, &res); err != nil {
+		return err
+	}
+	if matchColumn(noStatusColumn, colName) {
+		return nil
+	}
+	return c.setItemStatus(ctx, proj, res.AddProjectV2DraftIssue.ProjectItem.ID, colName)
+}
+
+// EditNote replaces the text of a note.
+func (c *Client) EditNote(ctx context.Context, ref ProjectRef, note Node, text string) error {
+	if ref.V2 {
+		title, body := splitNote(text)
+		req := c.newRequest(`mutation editDraftIssue($draftid: ID!, $title: String!, $body: String) {
+			updateProjectV2DraftIssue(input: {clientMutationId: "proj", draftIssueId: $draftid, title: $title, body: $body}) {
+				clientMutationId
+			}
+		}`)
+		req.Var("draftid", note.Content.ID)
+		req.Var("title", title)
+		req.Var("body", body)
+
+		res := struct{}{}
+		return c.mutate(ctx, req, &res)
+	}
+	req := c.newRequest(`mutation editNote($cardid: ID!, $note: String!) {
+		updateProjectCard(input: {clientMutationId: "proj", projectCardId: $cardid, note: $note}) {
+			clientMutationId
+		}
+	}`)
+	req.Var("cardid", note.ID)
+	req.Var("note", text)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// ConvertNote turns a note into an issue in repo, given as owner/name, and
+// returns the issue's URL. The issue keeps the note's place on the board. An
+// empty title uses the first line of the note.
+func (c *Client) ConvertNote(ctx context.Context, ref ProjectRef, note Node, repo, title string) (string, error) {
+	repoID, err := c.getRepositoryID(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	if ref.V2 {
+		if title != "" {
+			if _, body := splitNote(note.Note); body != "" {
+				title += "\n\n" + body
+			}
+			if err := c.EditNote(ctx, ref, note, title); err != nil {
+				return "", err
+			}
+		}
+		req := c.newRequest(`mutation convertDraftIssue($itemid: ID!, $repoid: ID!) {
+			convertProjectV2DraftIssueItemToIssue(input: {clientMutationId: "proj", itemId: $itemid, repositoryId: $repoid}) {
+				item {
+					content {
+						... on Issue {
+							url
+						}
+					}
+				}
+			}
+		}`)
+		req.Var("itemid", note.ID)
+		req.Var("repoid", repoID)
+
+		res := struct {
+			Convert struct {
+				Item struct {
+					Content Content `json:"content"`
+				} `json:"item"`
+			} `json:"convertProjectV2DraftIssueItemToIssue"`
+		}{}
+		if err := c.mutate(ctx, req, &res); err != nil {
+			return "", err
+		}
+		return res.Convert.Item.Content.URL, nil
+	}
+	noteTitle, body := splitNote(note.Note)
+	if title == "" {
+		title = noteTitle
+	} else {
+		body = note.Note
+	}
+	req := c.newRequest(`mutation convertNote($cardid: ID!, $repoid: ID!, $title: String, $body: String) {
+		convertProjectCardNoteToIssue(input: {clientMutationId: "proj", projectCardId: $cardid, repositoryId: $repoid, title: $title, body: $body}) {
+			projectCard {
+				content {
+					... on Issue {
+						url
+					}
+				}
+			}
+		}
+	}`)
+	req.Var("cardid", note.ID)
+	req.Var("repoid", repoID)
+	req.Var("title", title)
+	req.Var("body", body)
+
+	res := struct {
+		Convert struct {
+			ProjectCard struct {
+				Content Content `json:"content"`
+			} `json:"projectCard"`
+		} `json:"convertProjectCardNoteToIssue"`
+	}{}
+	if err := c.mutate(ctx, req, &res); err != nil {
+		return "", err
+	}
+	return res.Convert.ProjectCard.Content.URL, nil
+}
+
+// splitNote splits note text into a title, its first line, and a body.
+func splitNote(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	i := strings.IndexByte(text, '\n')
+	if i < 0 {
+		return text, ""
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+}
+
+// findNote finds a note by its card ID or by a part of its text that only
+// one note contains.
+func findNote(res *ProjectQueryResponse, key string) (Node, error) {
+	var matches []Node
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		for _, card := range col.Cards.Nodes {
+			if card.Content.Number != 0 {
+				continue
+			}
+			if card.ID == key {
+				return card, nil
+			}
+			if strings.Contains(strings.ToLower(card.Note), strings.ToLower(key)) {
+				matches = append(matches, card)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Node{}, fmt.Errorf("no note matches %q", key)
+	case 1:
+		return matches[0], nil
+	}
+	return Node{}, fmt.Errorf("%d notes match %q, use the card id from --format json", len(matches), key)
+}
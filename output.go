@@ -0,0 +1,516 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/mattn/go-runewidth"
+)
+
+var formats = []string{"table", "json", "jsonl", "csv", "tsv", "markdown", "template"}
+
+type outputOptions struct {
+	Format string
+	// Template is the template text used by the template format, or @path
+	// to read it from a file.
+	Template string
+	// Width is the number of columns available to the table format, or 0
+	// if titles shouldn't be truncated.
+	Width int
+	// Fields are the optional columns shown by the table format.
+	Fields []string
+}
+
+// cardFields are the optional columns that --show can add to the table.
+var cardFields = []string{"labels", "milestone", "state", "repo", "created", "updated", "closed"}
+
+// parseFields parses a comma separated list of cardFields.
+func parseFields(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		known := false
+		for _, k := range cardFields {
+			known = known || k == f
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q, expected any of %s", f, strings.Join(cardFields, ", "))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// fieldText returns the plain text of the optional column field for c.
+func fieldText(c Content, field string) string {
+	if c.Number == 0 {
+		return ""
+	}
+	switch field {
+	case "labels":
+		names := make([]string, len(c.Labels.Nodes))
+		for i, l := range c.Labels.Nodes {
+			names[i] = l.Name
+		}
+		return strings.Join(names, ",")
+	case "milestone":
+		if c.Milestone != nil {
+			return c.Milestone.Title
+		}
+	case "state":
+		return cardState(c)
+	case "repo":
+		return c.Repository.NameWithOwner
+	case "created":
+		return formatDate(c.CreatedAt)
+	case "updated":
+		return formatDate(c.UpdatedAt)
+	case "closed":
+		if c.ClosedAt != nil {
+			return formatDate(*c.ClosedAt)
+		}
+	}
+	return ""
+}
+
+// cardState is the lower case state of c, with open draft pull requests
+// reported as draft.
+func cardState(c Content) string {
+	if c.IsDraft && c.State == "OPEN" {
+		return "draft"
+	}
+	return strings.ToLower(c.State)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+// labelColor renders name in the label's hex color using a 24-bit escape
+// sequence, as fatih/color has no RGB support.
+func labelColor(l Label) string {
+	var r, g, b int
+	if color.NoColor || len(l.Color) != 6 {
+		return l.Name
+	}
+	if _, err := fmt.Sscanf(l.Color, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return l.Name
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, l.Name)
+}
+
+// minTitleWidth stops narrow terminals squeezing titles down to nothing.
+const minTitleWidth = 20
+
+// setColorMode enables or disables colored output for the mode auto, always
+// or never. In auto mode color is used only when stdout is a terminal and
+// NO_COLOR isn't set.
+func setColorMode(mode string) error {
+	switch mode {
+	case "always":
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	case "", "auto":
+		color.NoColor = os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isatty.IsTerminal(os.Stdout.Fd())
+	default:
+		return fmt.Errorf("unknown color mode %q, expected auto, always or never", mode)
+	}
+	return nil
+}
+
+// outputWidth returns the width of the terminal f is attached to, falling
+// back to $COLUMNS, or 0 if neither is known.
+func outputWidth(f *os.File) int {
+	if isatty.IsTerminal(f.Fd()) {
+		if w := terminalWidth(f.Fd()); w > 0 {
+			return w
+		}
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// cardRow is the flattened form of a card used by the line based formats
+// and templates.
+type cardRow struct {
+	Column     string            `json:"column"`
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Number     int               `json:"number,omitempty"`
+	Title      string            `json:"title"`
+	Owner      string            `json:"owner,omitempty"`
+	Author     string            `json:"author,omitempty"`
+	Assignees  []string          `json:"assignees,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	Repository string            `json:"repository,omitempty"`
+	State      string            `json:"state,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`
+	Milestone  string            `json:"milestone,omitempty"`
+	CreatedAt  *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt  *time.Time        `json:"updatedAt,omitempty"`
+	ClosedAt   *time.Time        `json:"closedAt,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+func newCardRow(col ColumnNode, card Node) cardRow {
+	row := cardRow{
+		Column: col.Name,
+		ID:     card.ID,
+		Fields: card.Fields,
+	}
+	if card.Content.Number == 0 {
+		row.Type = "note"
+		row.Title = card.Note
+		return row
+	}
+	row.Type = "issue"
+	if strings.Contains(card.Content.URL, "pull") {
+		row.Type = "pr"
+	}
+	row.Number = card.Content.Number
+	row.Title = card.Content.Title
+	row.Owner = getOwner(card.Content)
+	row.Author = card.Content.Author.Login
+	row.URL = card.Content.URL
+	for _, a := range card.Content.Assignees.Edges {
+		row.Assignees = append(row.Assignees, a.Node.Login)
+	}
+	row.Repository = card.Content.Repository.NameWithOwner
+	row.State = cardState(card.Content)
+	for _, l := range card.Content.Labels.Nodes {
+		row.Labels = append(row.Labels, l.Name)
+	}
+	if card.Content.Milestone != nil {
+		row.Milestone = card.Content.Milestone.Title
+	}
+	if !card.Content.CreatedAt.IsZero() {
+		row.CreatedAt = &card.Content.CreatedAt
+	}
+	if !card.Content.UpdatedAt.IsZero() {
+		row.UpdatedAt = &card.Content.UpdatedAt
+	}
+	row.ClosedAt = card.Content.ClosedAt
+	return row
+}
+
+func cardRows(res *ProjectQueryResponse) []cardRow {
+	var rows []cardRow
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		for _, card := range col.Cards.Nodes {
+			rows = append(rows, newCardRow(col, card))
+		}
+	}
+	return rows
+}
+
+// filterCards returns a copy of res with only the cards that keep returns
+// true for.
+func filterCards(res *ProjectQueryResponse, keep func(ColumnNode, Node) bool) *ProjectQueryResponse {
+	out := *res
+	cols := make([]ColumnNode, 0, len(res.Owner.Project.Columns.Nodes))
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		cards := make([]Node, 0, len(col.Cards.Nodes))
+		for _, card := range col.Cards.Nodes {
+			if keep(col, card) {
+				cards = append(cards, card)
+			}
+		}
+		col.Cards.Nodes = cards
+		cols = append(cols, col)
+	}
+	out.Owner.Project.Columns.Nodes = cols
+	return &out
+}
+
+func writeProject(w io.Writer, res *ProjectQueryResponse, opts outputOptions) error {
+	switch opts.Format {
+	case "", "table":
+		return writeTable(w, res, opts.Width, opts.Fields)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, row := range cardRows(res) {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv", "tsv":
+		return writeCSV(w, res, opts.Format == "tsv")
+	case "markdown":
+		return writeMarkdown(w, res)
+	case "template":
+		return writeTemplate(w, res, opts.Template)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", opts.Format, strings.Join(formats, ", "))
+}
+
+// writeTable lists the cards column by column. Optional fields go between
+// the title and the URL, except labels which go last.
+func writeTable(out io.Writer, res *ProjectQueryResponse, width int, fields []string) error {
+	var plain []string
+	showLabels := false
+	for _, f := range fields {
+		if f == "labels" {
+			showLabels = true
+		} else {
+			plain = append(plain, f)
+		}
+	}
+
+	titleWidth := 0
+	if width > 0 {
+		var numberWidth, ownerWidth, urlWidth, labelsWidth int
+		fieldWidths := make([]int, len(plain))
+		for _, col := range res.Owner.Project.Columns.Nodes {
+			ownerWidth = maxInt(ownerWidth, runewidth.StringWidth(col.Name))
+			for _, card := range col.Cards.Nodes {
+				numberWidth = maxInt(numberWidth, len(fmt.Sprint(card.Content.Number)))
+				ownerWidth = maxInt(ownerWidth, runewidth.StringWidth(getOwner(card.Content)))
+				urlWidth = maxInt(urlWidth, runewidth.StringWidth(card.Content.URL))
+				for i, f := range plain {
+					fieldWidths[i] = maxInt(fieldWidths[i], runewidth.StringWidth(fieldText(card.Content, f)))
+				}
+				if showLabels {
+					labelsWidth = maxInt(labelsWidth, runewidth.StringWidth(fieldText(card.Content, "labels")))
+				}
+			}
+		}
+		// every cell but the last is padded by a space, and the title gets
+		// "..." appended when it's cut short.
+		used := numberWidth + ownerWidth + urlWidth + 3 + 3
+		for _, fw := range fieldWidths {
+			used += fw + 1
+		}
+		if showLabels {
+			used += labelsWidth + 1
+		}
+		titleWidth = maxInt(width-used, minTitleWidth)
+	}
+
+	// blank fills the cells of a header or note row out to the URL, plus an
+	// empty last cell so that every cell it has is padded.
+	blank := func(cells ...tableCell) []tableCell {
+		for range plain {
+			cells = append(cells, tableCell{})
+		}
+		if showLabels {
+			cells = append(cells, tableCell{})
+		}
+		return append(cells, tableCell{})
+	}
+
+	var rows [][]tableCell
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		rows = append(rows, blank(tableCell{}, newCell(col.Name, color.GreenString), tableCell{}))
+		for _, card := range col.Cards.Nodes {
+			if card.Note != "" {
+				rows = append(rows, blank(tableCell{}, tableCell{}, newCell(capStr(oneLine(card.Note), titleWidth), nil)))
+				continue
+			}
+			cells := []tableCell{
+				newCell(fmt.Sprint(card.Content.Number), color.BlueString),
+				newCell(getOwner(card.Content), color.MagentaString),
+				newCell(capStr(card.Content.Title, titleWidth), nil),
+			}
+			for _, f := range plain {
+				cells = append(cells, newCell(fieldText(card.Content, f), nil))
+			}
+			cells = append(cells, newCell(card.Content.URL, color.CyanString))
+			if showLabels {
+				labels := make([]string, len(card.Content.Labels.Nodes))
+				for i, l := range card.Content.Labels.Nodes {
+					labels[i] = labelColor(l)
+				}
+				cells = append(cells, tableCell{text: strings.Join(labels, ",")})
+			}
+			rows = append(rows, cells)
+		}
+	}
+	return writeCells(out, rows)
+}
+
+// tableCell is the text of a table cell, which may be colored, and the
+// width it takes up on the terminal.
+type tableCell struct {
+	text  string
+	width int
+}
+
+// newCell makes a cell of s, colored by paint if it isn't nil.
+func newCell(s string, paint func(string, ...interface{}) string) tableCell {
+	cell := tableCell{text: s, width: runewidth.StringWidth(s)}
+	if paint != nil {
+		cell.text = paint("%s", s)
+	}
+	return cell
+}
+
+// writeCells writes rows aligned in columns, padding every cell but the
+// last in its row to the width of the widest cell in its column. Unlike
+// tabwriter, widths go by how wide the text shows rather than its runes or
+// bytes, so wide characters and color escapes don't throw them out.
+func writeCells(out io.Writer, rows [][]tableCell) error {
+	var widths []int
+	for _, row := range rows {
+		for i := 0; i < len(row)-1; i++ {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = maxInt(widths[i], row[i].width)
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			b.WriteString(cell.text)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-cell.width+1))
+			}
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func writeCSV(out io.Writer, res *ProjectQueryResponse, tabs bool) error {
+	w := csv.NewWriter(out)
+	if tabs {
+		w.Comma = '\t'
+	}
+	w.Write([]string{"column", "type", "number", "title", "owner", "author", "assignees", "url",
+		"repository", "state", "labels", "milestone", "created", "updated", "closed"})
+	for _, row := range cardRows(res) {
+		number := ""
+		if row.Number != 0 {
+			number = fmt.Sprint(row.Number)
+		}
+		w.Write([]string{
+			row.Column,
+			row.Type,
+			number,
+			row.Title,
+			row.Owner,
+			row.Author,
+			strings.Join(row.Assignees, ","),
+			row.URL,
+			row.Repository,
+			row.State,
+			strings.Join(row.Labels, ","),
+			row.Milestone,
+			csvTime(row.CreatedAt),
+			csvTime(row.UpdatedAt),
+			csvTime(row.ClosedAt),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeMarkdown(w io.Writer, res *ProjectQueryResponse) error {
+	fmt.Fprintf(w, "# %s\n", escapeMarkdown(res.Owner.Project.Name))
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		fmt.Fprintf(w, "\n## %s\n\n", escapeMarkdown(col.Name))
+		if len(col.Cards.Nodes) == 0 {
+			fmt.Fprintln(w, "_No cards_")
+			continue
+		}
+		fmt.Fprintln(w, "| # | Title | Owner |")
+		fmt.Fprintln(w, "| --- | --- | --- |")
+		for _, card := range col.Cards.Nodes {
+			row := newCardRow(col, card)
+			number := ""
+			if row.Number != 0 {
+				number = fmt.Sprintf("[#%d](%s)", row.Number, row.URL)
+			}
+			owner := ""
+			if row.Owner != "" {
+				owner = "@" + row.Owner
+			}
+			fmt.Fprintf(w, "| %s | %s | %s |\n", number, escapeMarkdown(row.Title), owner)
+		}
+	}
+	return nil
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trunc": capStr,
+	"owner": getOwner,
+}
+
+// writeTemplate executes tmpl with the project, its columns and the
+// flattened cards available as .Project, .Columns and .Cards.
+func writeTemplate(w io.Writer, res *ProjectQueryResponse, tmpl string) error {
+	if tmpl == "" {
+		return fmt.Errorf("the template format needs --template")
+	}
+	if strings.HasPrefix(tmpl, "@") {
+		b, err := ioutil.ReadFile(tmpl[1:])
+		if err != nil {
+			return err
+		}
+		tmpl = string(b)
+	}
+	t, err := template.New("proj").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, struct {
+		Project Project
+		Columns []ColumnNode
+		Cards   []cardRow
+	}{
+		Project: res.Owner.Project,
+		Columns: res.Owner.Project.Columns.Nodes,
+		Cards:   cardRows(res),
+	})
+}
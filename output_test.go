@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+)
+
+func TestWriteCellsWideText(t *testing.T) {
+	defer func(old bool) { color.NoColor = old }(color.NoColor)
+	color.NoColor = false
+
+	rows := [][]tableCell{
+		{newCell("1", color.BlueString), newCell("修正する", nil), newCell("https://a", color.CyanString)},
+		{newCell("22", color.BlueString), newCell("fix it", nil), newCell("https://b", color.CyanString)},
+	}
+	var b strings.Builder
+	if err := writeCells(&b, rows); err != nil {
+		t.Fatal(err)
+	}
+	var urlAt []int
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		plain := stripEscapes(line)
+		i := strings.Index(plain, "https://")
+		urlAt = append(urlAt, runewidth.StringWidth(plain[:i]))
+	}
+	if len(urlAt) != 2 || urlAt[0] != urlAt[1] || urlAt[0] != 12 {
+		t.Errorf("URLs start at columns %v, want both at 12:\n%s", urlAt, b.String())
+	}
+}
+
+// stripEscapes removes the color escape sequences from s.
+func stripEscapes(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "\x1b[")
+		if i < 0 {
+			return b.String() + s
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		s = s[strings.IndexByte(s, 'm')+1:]
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/machinebox/graphql"
+)
+
+const (
+	defaultStatusField = "Status"
+	noStatusColumn     = "No Status"
+)
+
+// DetectProjectV2 reports whether the project number refers to a ProjectV2
+// board for the owner.
+func (c *Client) DetectProjectV2(ctx context.Context, ref ProjectRef) (bool, error) {
+	id, err := c.projectV2ID(ctx, ref)
+	return id != "", err
+}
+
+// projectV2ID returns the node ID of the ProjectV2 board, or "" if there is
+// none with the ref's number.
+func (c *Client) projectV2ID(ctx context.Context, ref ProjectRef) (string, error) {
+	vars, root := ref.queryRoot()
+	req := c.newRequest(fmt.Sprintf(`query detectProjectV2($project: Int!, %s) {
+		%s {
+			projectV2(number: $project) {
+				id
+			}
+		}
+	}`, vars, root))
+	req.Var("project", ref.Number)
+	ref.setVars(req)
+
+	res := struct {
+		Owner struct {
+			ProjectV2 *struct {
+				ID string `json:"id"`
+			} `json:"projectV2"`
+		} `json:"owner"`
+	}{}
+	var errs []graphQLError
+	err := c.query(withErrors(ctx, &errs), req, &res)
+	if err != nil && !projectNotFound(errs) {
+		return "", err
+	}
+	if res.Owner.ProjectV2 == nil {
+		return "", nil
+	}
+	return res.Owner.ProjectV2.ID, nil
+}
+
+// projectNotFound reports whether errs only say that there's no
+// projectV2 with the number asked for.
+func projectNotFound(errs []graphQLError) bool {
+	for _, e := range errs {
+		if e.Type != "NOT_FOUND" || len(e.Path) == 0 || e.Path[len(e.Path)-1] != "projectV2" {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+type projectV2 struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Number      int    `json:"number"`
+	StatusField *struct {
+		Typename string `json:"__typename"`
+		ID       string `json:"id"`
+		Options  []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"options"`
+	} `json:"field"`
+	Items struct {
+		PageInfo PageInfo        `json:"pageInfo"`
+		Nodes    []projectV2Item `json:"nodes"`
+	} `json:"items"`
+}
+
+type projectV2Item struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	IsArchived  bool   `json:"isArchived"`
+	FieldValues struct {
+		Nodes []projectV2FieldValue `json:"nodes"`
+	} `json:"fieldValues"`
+	Content struct {
+		Content
+		Typename string `json:"__typename"`
+		// Body is only fetched for draft issues.
+		Body string `json:"body"`
+	} `json:"content"`
+}
+
+type projectV2FieldValue struct {
+	Name     string   `json:"name"`
+	OptionID string   `json:"optionId"`
+	Text     string   `json:"text"`
+	Date     string   `json:"date"`
+	Title    string   `json:"title"`
+	Number   *float64 `json:"number"`
+	Field    struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"field"`
+}
+
+func (v projectV2FieldValue) String() string {
+	switch {
+	case v.OptionID != "":
+		return v.Name
+	case v.Number != nil:
+		return fmt.Sprint(*v.Number)
+	case v.Date != "":
+		return v.Date
+	case v.Title != "":
+		return v.Title
+	}
+	return v.Text
+}
+
+// getProjectV2 fetches a ProjectV2 board and maps it onto the classic
+// column/card model: each option of the status field becomes a column, and
+// draft issues become notes.
+func (c *Client) getProjectV2(ctx context.Context, ref ProjectRef) (*ProjectQueryResponse, error) {
+	statusField := ref.StatusField
+	if statusField == "" {
+		statusField = defaultStatusField
+	}
+	vars, root := ref.queryRoot()
+	var (
+		proj      projectV2
+		items     []projectV2Item
+		cursor    string
+		truncated bool
+		rateLimit RateLimit
+	)
+	for page := 0; ; page++ {
+		if page == maxPages {
+			truncated = true
+			break
+		}
+		req := c.newRequest(fmt.Sprintf(viewProjectV2Query, vars, root) + contentFragments)
+		req.Var("project", ref.Number)
+		req.Var("status", statusField)
+		req.Var("after", nullable(cursor))
+		ref.setVars(req)
+
+		res := struct {
+			RateLimit RateLimit `json:"rateLimit"`
+			Owner     struct {
+				ProjectV2 *projectV2 `json:"projectV2"`
+			} `json:"owner"`
+		}{}
+		err := c.query(ctx, req, &res)
+		if err != nil {
+			return nil, err
+		}
+		if res.Owner.ProjectV2 == nil {
+			return nil, fmt.Errorf("couldn't find project %s", ref)
+		}
+		proj = *res.Owner.ProjectV2
+		rateLimit = rateLimit.add(res.RateLimit)
+		items = append(items, proj.Items.Nodes...)
+		if !proj.Items.PageInfo.HasNextPage {
+			break
+		}
+		cursor = proj.Items.PageInfo.EndCursor
+	}
+	if proj.StatusField == nil || proj.StatusField.Typename != "ProjectV2SingleSelectField" {
+		return nil, fmt.Errorf("project %s has no single select field %q", ref, statusField)
+	}
+	if proj.StatusField.ID == "" {
+		return nil, fmt.Errorf("couldn't get the ID of field %q of project %s", statusField, ref)
+	}
+
+	res := ProjectQueryResponse{Truncated: truncated, RateLimit: rateLimit}
+	res.Owner.Project = Project{
+		ID:            proj.ID,
+		Name:          proj.Title,
+		Number:        proj.Number,
+		StatusFieldID: proj.StatusField.ID,
+	}
+	cols := []ColumnNode{{Name: noStatusColumn}}
+	colIndex := map[string]int{}
+	for _, opt := range proj.StatusField.Options {
+		colIndex[opt.ID] = len(cols)
+		cols = append(cols, ColumnNode{Name: opt.Name, ID: opt.ID})
+	}
+	for _, item := range items {
+		if item.Type == "REDACTED" || item.IsArchived != ref.Archived {
+			continue
+		}
+		card := Node{ID: item.ID, Content: item.Content.Content, IsArchived: item.IsArchived}
+		if item.Content.Typename == "DraftIssue" {
+			card.Note = item.Content.Title
+			if item.Content.Body != "" {
+				card.Note += "\n\n" + item.Content.Body
+			}
+			card.Content = Content{ID: item.Content.ID}
+		}
+		col := 0
+		for _, v := range item.FieldValues.Nodes {
+			if v.Field.Name == "" {
+				continue
+			}
+			if v.Field.ID == proj.StatusField.ID {
+				if i, ok := colIndex[v.OptionID]; ok {
+					col = i
+				}
+				continue
+			}
+			if card.Fields == nil {
+				card.Fields = make(map[string]string)
+			}
+			card.Fields[v.Field.Name] = v.String()
+		}
+		trunc, err := c.getRemainingAssignees(ctx, &card.Content)
+		if err != nil {
+			return nil, err
+		}
+		res.Truncated = res.Truncated || trunc
+		cols[col].Cards.Nodes = append(cols[col].Cards.Nodes, card)
+	}
+	if len(cols[0].Cards.Nodes) == 0 {
+		cols = cols[1:]
+	}
+	res.Owner.Project.Columns.Nodes = cols
+	return &res, nil
+}
+
+// setItemStatus sets the status field of a ProjectV2 item to the option
+// named colName, or clears it for the "No Status" column.
+func (c *Client) setItemStatus(ctx context.Context, proj Project, itemID, colName string) error {
+	var (
+		optionID    string
+		clearStatus = matchColumn(noStatusColumn, colName)
+	)
+	for _, col := range proj.Columns.Nodes {
+		if col.ID != "" && matchColumn(col.Name, colName) {
+			optionID = col.ID
+		}
+	}
+	if optionID == "" && !clearStatus {
+		return fmt.Errorf("couldn't move card: no column %q", colName)
+	}
+	var req *graphql.Request
+	if optionID != "" {
+		req = c.newRequest(`mutation moveItem($projectid: ID!, $itemid: ID!, $fieldid: ID!, $optionid: String!) {
+			updateProjectV2ItemFieldValue(input: {projectId: $projectid, itemId: $itemid, fieldId: $fieldid, value: {singleSelectOptionId: $optionid}}) {
+				clientMutationId
+			}
+		}`)
+		req.Var("optionid", optionID)
+	} else {
+		req = c.newRequest(`mutation clearItemStatus($projectid: ID!, $itemid: ID!, $fieldid: ID!) {
+			clearProjectV2ItemFieldValue(input: {projectId: $projectid, itemId: $itemid, fieldId: $fieldid}) {
+				clientMutationId
+			}
+		}`)
+	}
+	req.Var("projectid", proj.ID)
+	req.Var("itemid", itemID)
+	req.Var("fieldid", proj.StatusFieldID)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+const viewProjectV2Query = `query viewProjectV2($project: Int!, $status: String!, $after: String, %s) {
+  rateLimit {
+    limit
+    remaining
+    cost
+    resetAt
+  }
+  %s {
+    projectV2(number: $project) {
+      id
+      title
+      number
+      field(name: $status) {
+        __typename
+        ... on ProjectV2FieldCommon {
+          id
+        }
+        ... on ProjectV2SingleSelectField {
+          options {
+            id
+            name
+          }
+        }
+      }
+      items(first: 50, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          type
+          isArchived
+          fieldValues(first: 30) {
+            nodes {
+              ... on ProjectV2ItemFieldSingleSelectValue {
+                name
+                optionId
+                field {
+                  ... on ProjectV2FieldCommon {
+                    id
+                    name
+                  }
+                }
+              }
+              ... on ProjectV2ItemFieldTextValue {
+                text
+                field {
+                  ... on ProjectV2FieldCommon {
+                    id
+                    name
+                  }
+                }
+              }
+              ... on ProjectV2ItemFieldNumberValue {
+                number
+                field {
+                  ... on ProjectV2FieldCommon {
+                    id
+                    name
+                  }
+                }
+              }
+              ... on ProjectV2ItemFieldDateValue {
+                date
+                field {
+                  ... on ProjectV2FieldCommon {
+                    id
+                    name
+                  }
+                }
+              }
+              ... on ProjectV2ItemFieldIterationValue {
+                title
+                field {
+                  ... on ProjectV2FieldCommon {
+                    id
+                    name
+                  }
+                }
+              }
+            }
+          }
+          content {
+            __typename
+            ... on DraftIssue {
+              id
+              title
+              body
+            }
+            ...issueFields
+            ...pullRequestFields
+          }
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProjectNotFound(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"data":{"owner":{"projectV2":null}},"errors":[{"type":"NOT_FOUND","path":["owner","projectV2"],"message":"Could not resolve to a ProjectV2 with the number 3."}]}`, true},
+		{`{"data":{"owner":null},"errors":[{"type":"NOT_FOUND","path":["owner"],"message":"Could not resolve to an Organization with the login of 'x'."}]}`, false},
+		{`{"data":null,"errors":[{"type":"FORBIDDEN","path":["owner","projectV2"],"message":"Resource not accessible"}]}`, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, tt.body)
+		}))
+		c := NewClient("token", WithEndpoint(srv.URL))
+		_, err := c.projectV2ID(context.Background(), ProjectRef{Owner: "x", Number: 3})
+		srv.Close()
+		if got := err == nil; got != tt.want {
+			t.Errorf("projectV2ID() for %s: error = %v", tt.body, err)
+		}
+	}
+}
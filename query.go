@@ -3,40 +3,322 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/machinebox/graphql"
 )
 
-func GetProject(ctx context.Context, id int) (*ProjectQueryResponse, error) {
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(viewProjectQuery)
-	req.Var("project", id)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
+type OwnerType string
 
-	res := ProjectQueryResponse{}
-	err := client.Run(ctx, req, &res)
+const (
+	OwnerOrganization OwnerType = "organization"
+	OwnerUser         OwnerType = "user"
+	OwnerRepository   OwnerType = "repository"
+)
+
+// ProjectRef identifies a project board by its owner and number. Owner is
+// either an organization or user login, or owner/name for a repository.
+type ProjectRef struct {
+	Owner     string
+	OwnerType OwnerType
+	Number    int
+	// V2 selects the Projects (ProjectV2) backend instead of classic projects.
+	V2 bool
+	// StatusField names the single-select field whose options are used as
+	// columns on a ProjectV2 board. Defaults to "Status".
+	StatusField string
+	// Archived makes GetProject return the archived cards instead of the
+	// active ones.
+	Archived bool
+}
+
+func (r ProjectRef) String() string {
+	return fmt.Sprintf("%s/%d", r.Owner, r.Number)
+}
+
+// Repo returns owner/name for repository projects, or "" otherwise.
+func (r ProjectRef) Repo() string {
+	if r.OwnerType == OwnerRepository {
+		return r.Owner
+	}
+	return ""
+}
+
+// queryRoot returns the variable declarations and selection used to reach
+// the owner of the project, aliased to "owner" so responses decode the same
+// way regardless of the owner type.
+func (r ProjectRef) queryRoot() (string, string) {
+	switch r.OwnerType {
+	case OwnerUser:
+		return "$owner: String!", "owner: user(login: $owner)"
+	case OwnerRepository:
+		return "$owner: String!, $repo: String!", "owner: repository(owner: $owner, name: $repo)"
+	default:
+		return "$owner: String!", "owner: organization(login: $owner)"
+	}
+}
+
+// archivedStates is the archivedStates argument for the cards of classic
+// project columns.
+func (r ProjectRef) archivedStates() []string {
+	if r.Archived {
+		return []string{"ARCHIVED"}
+	}
+	return []string{"NOT_ARCHIVED"}
+}
+
+func (r ProjectRef) setVars(req *graphql.Request) {
+	if r.OwnerType == OwnerRepository {
+		parts := strings.SplitN(r.Owner, "/", 2)
+		req.Var("owner", parts[0])
+		req.Var("repo", parts[1])
+		return
+	}
+	req.Var("owner", r.Owner)
+}
+
+func (c *Client) DetectOwnerType(ctx context.Context, owner string) (OwnerType, error) {
+	if strings.Contains(owner, "/") {
+		parts := strings.SplitN(owner, "/", 2)
+		if parts[0] == "" || parts[1] == "" {
+			return "", fmt.Errorf("invalid repository owner %q", owner)
+		}
+		return OwnerRepository, nil
+	}
+	req := c.newRequest(`query ownerType($login: String!) {
+		repositoryOwner(login: $login) {
+			__typename
+		}
+	}`)
+	req.Var("login", owner)
+
+	res := struct {
+		RepositoryOwner *struct {
+			Typename string `json:"__typename"`
+		} `json:"repositoryOwner"`
+	}{}
+	err := c.query(ctx, req, &res)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if res.RepositoryOwner == nil {
+		return "", fmt.Errorf("couldn't find owner %q", owner)
+	}
+	switch res.RepositoryOwner.Typename {
+	case "Organization":
+		return OwnerOrganization, nil
+	case "User":
+		return OwnerUser, nil
+	}
+	return "", fmt.Errorf("unsupported owner type %s for %q", res.RepositoryOwner.Typename, owner)
+}
+
+func (c *Client) GetProject(ctx context.Context, ref ProjectRef) (*ProjectQueryResponse, error) {
+	if ref.OwnerType == "" {
+		ot, err := c.DetectOwnerType(ctx, ref.Owner)
+		if err != nil {
+			return nil, err
+		}
+		ref.OwnerType = ot
+	}
+	if ref.V2 {
+		return c.getProjectV2(ctx, ref)
+	}
+	vars, root := ref.queryRoot()
+	var (
+		res    ProjectQueryResponse
+		cursor string
+	)
+	for page := 0; ; page++ {
+		if page == maxPages {
+			res.Truncated = true
+			break
+		}
+		req := c.newRequest(fmt.Sprintf(viewProjectQuery, vars, root) + cardFragment)
+		req.Var("project", ref.Number)
+		req.Var("after", nullable(cursor))
+		req.Var("archived", ref.archivedStates())
+		ref.setVars(req)
+
+		pageRes := ProjectQueryResponse{}
+		err := c.query(ctx, req, &pageRes)
+		if err != nil {
+			return nil, err
+		}
+		if pageRes.Owner.Project.Number == 0 {
+			return nil, fmt.Errorf("couldn't find project %s", ref)
+		}
+		cols := append(res.Owner.Project.Columns.Nodes, pageRes.Owner.Project.Columns.Nodes...)
+		res.Owner.Project = pageRes.Owner.Project
+		res.RateLimit = res.RateLimit.add(pageRes.RateLimit)
+		res.Owner.Project.Columns.Nodes = cols
+		if !pageRes.Owner.Project.Columns.PageInfo.HasNextPage {
+			break
+		}
+		cursor = pageRes.Owner.Project.Columns.PageInfo.EndCursor
+	}
+
+	for i := range res.Owner.Project.Columns.Nodes {
+		col := &res.Owner.Project.Columns.Nodes[i]
+		truncated, err := c.getRemainingCards(ctx, ref, col)
+		if err != nil {
+			return nil, err
+		}
+		res.Truncated = res.Truncated || truncated
+		for j := range col.Cards.Nodes {
+			truncated, err := c.getRemainingAssignees(ctx, &col.Cards.Nodes[j].Content)
+			if err != nil {
+				return nil, err
+			}
+			res.Truncated = res.Truncated || truncated
+		}
 	}
 	return &res, nil
 }
 
+// maxPages bounds how many pages of any one connection GetProject will walk,
+// so that a runaway board can't hang the CLI. Hitting it marks the response
+// as truncated.
+const maxPages = 50
+
+func (c *Client) getRemainingCards(ctx context.Context, ref ProjectRef, col *ColumnNode) (bool, error) {
+	for page := 0; col.Cards.PageInfo.HasNextPage; page++ {
+		if page == maxPages {
+			return true, nil
+		}
+		req := c.newRequest(`query columnCards($id: ID!, $after: String, $archived: [ProjectCardArchivedState]) {
+			node(id: $id) {
+				... on ProjectColumn {
+					cards(first: 100, after: $after, archivedStates: $archived) {
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+						nodes {
+							...cardFields
+						}
+					}
+				}
+			}
+		}` + cardFragment)
+		req.Var("id", col.ID)
+		req.Var("after", col.Cards.PageInfo.EndCursor)
+		req.Var("archived", ref.archivedStates())
+
+		res := struct {
+			Node struct {
+				Cards Cards `json:"cards"`
+			} `json:"node"`
+		}{}
+		err := c.query(ctx, req, &res)
+		if err != nil {
+			return false, err
+		}
+		col.Cards.Nodes = append(col.Cards.Nodes, res.Node.Cards.Nodes...)
+		col.Cards.PageInfo = res.Node.Cards.PageInfo
+	}
+	return false, nil
+}
+
+func (c *Client) getRemainingAssignees(ctx context.Context, content *Content) (bool, error) {
+	for page := 0; content.Assignees.PageInfo.HasNextPage; page++ {
+		if page == maxPages {
+			return true, nil
+		}
+		req := c.newRequest(`query assignees($id: ID!, $after: String) {
+			node(id: $id) {
+				... on Issue {
+					assignees(first: 100, after: $after) {
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+						edges {
+							node {
+								login
+							}
+						}
+					}
+				}
+			}
+		}`)
+		req.Var("id", content.ID)
+		req.Var("after", content.Assignees.PageInfo.EndCursor)
+
+		res := struct {
+			Node struct {
+				Assignees Assignees `json:"assignees"`
+			} `json:"node"`
+		}{}
+		err := c.query(ctx, req, &res)
+		if err != nil {
+			return false, err
+		}
+		content.Assignees.Edges = append(content.Assignees.Edges, res.Node.Assignees.Edges...)
+		content.Assignees.PageInfo = res.Node.Assignees.PageInfo
+	}
+	return false, nil
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
 type Author struct {
 	Login string `json:"login"`
 }
 type Content struct {
-	ID        string    `json:"id"`
-	Author    Author    `json:"author"`
-	Number    int       `json:"number"`
-	Title     string    `json:"title"`
-	URL       string    `json:"url"`
-	Assignees Assignees `json:"assignees"`
+	ID         string     `json:"id"`
+	Author     Author     `json:"author"`
+	Number     int        `json:"number"`
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	State      string     `json:"state"`
+	IsDraft    bool       `json:"isDraft"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	ClosedAt   *time.Time `json:"closedAt"`
+	Repository Repository `json:"repository"`
+	Assignees  Assignees  `json:"assignees"`
+	Labels     Labels     `json:"labels"`
+	Milestone  *Milestone `json:"milestone"`
+	// Body is only fetched for single issues, not for whole boards.
+	Body string `json:"body,omitempty"`
+}
+
+type Repository struct {
+	NameWithOwner string `json:"nameWithOwner"`
+}
+
+type Labels struct {
+	Nodes []Label `json:"nodes"`
+}
+
+type Label struct {
+	// ID is only fetched when choosing labels, not for whole boards.
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type Milestone struct {
+	// ID is only fetched when choosing a milestone, not for whole boards.
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title"`
 }
 
 type Assignees struct {
-	Edges []AssigneeNode `json:"edges"`
+	PageInfo PageInfo       `json:"pageInfo"`
+	Edges    []AssigneeNode `json:"edges"`
 }
 type AssigneeNode struct {
 	Node Assignee `json:"node"`
@@ -50,9 +332,14 @@ type Node struct {
 	ID      string  `json:"id"`
 	Content Content `json:"content"`
 	Note    string  `json:"note"`
+	// IsArchived is set on cards fetched with ProjectRef.Archived.
+	IsArchived bool `json:"isArchived,omitempty"`
+	// Fields holds the custom field values of a ProjectV2 item by field name.
+	Fields map[string]string `json:"fields,omitempty"`
 }
 type Cards struct {
-	Nodes []Node `json:"nodes"`
+	PageInfo PageInfo `json:"pageInfo"`
+	Nodes    []Node   `json:"nodes"`
 }
 type ColumnNode struct {
 	Cards Cards  `json:"cards"`
@@ -60,58 +347,77 @@ type ColumnNode struct {
 	ID    string `json:"id"`
 }
 type Columns struct {
-	Nodes []ColumnNode `json:"nodes"`
+	PageInfo PageInfo     `json:"pageInfo"`
+	Nodes    []ColumnNode `json:"nodes"`
 }
 type Project struct {
+	ID      string  `json:"id"`
 	Columns Columns `json:"columns"`
 	Name    string  `json:"name"`
 	Number  int     `json:"number"`
+	// StatusFieldID is the ProjectV2 field that columns are derived from.
+	StatusFieldID string `json:"statusFieldId,omitempty"`
 }
-type Organization struct {
+type Owner struct {
 	Project Project `json:"project"`
 }
 type ProjectQueryResponse struct {
-	Organization Organization `json:"organization"`
+	Owner Owner `json:"owner"`
+	// Truncated is set when GetProject gave up paginating before reaching the
+	// end of the board.
+	Truncated bool      `json:"truncated"`
+	RateLimit RateLimit `json:"rateLimit"`
+}
+
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Cost      int       `json:"cost"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// add combines the rate limit reported by a later request, keeping the
+// latest budget and summing the cost.
+func (r RateLimit) add(next RateLimit) RateLimit {
+	next.Cost += r.Cost
+	return next
+}
+
+func (r RateLimit) String() string {
+	if r.Limit == 0 {
+		return ""
+	}
+	return fmt.Sprintf("rate limit %d/%d, resets %s", r.Remaining, r.Limit, r.ResetAt.Local().Format("15:04"))
 }
 
-const viewProjectQuery = `query viewProject($project: Int!) {
-  organization(login: "sourcegraph") {
+// viewProjectQuery fetches a page of columns, each with its first page of
+// cards. The page sizes keep the whole query under GitHub's node limit;
+// GetProject fetches any remaining cards and assignees separately.
+const viewProjectQuery = `query viewProject($project: Int!, $after: String, $archived: [ProjectCardArchivedState], %s) {
+  rateLimit {
+    limit
+    remaining
+    cost
+    resetAt
+  }
+  %s {
     project(number: $project) {
-			name
-			number
-      columns(first: 10) {
+      id
+      name
+      number
+      columns(first: 20, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
         nodes {
-          cards {
+          cards(first: 50, archivedStates: $archived) {
+            pageInfo {
+              hasNextPage
+              endCursor
+            }
             nodes {
-              id
-							note
-              content {
-                ... on Issue {
-                  id
-                  author {
-                    login
-                  }
-                  number
-                  title
-									url
-									assignees(first: 10) {
-                    edges {
-                      node {
-                        login
-                      }
-                    }
-                  }
-                }
-                ... on PullRequest {
-                  id
-                  author {
-                    login
-                  }
-                  number
-                  title
-									url
-                }
-              }
+              ...cardFields
             }
           }
           name
@@ -121,150 +427,334 @@ const viewProjectQuery = `query viewProject($project: Int!) {
     }
   }
 }
-	`
+`
 
-func AssignIssue(ctx context.Context, user string, issue Content) error {
-	userID, err := getUserID(ctx, user)
+const cardFragment = `
+fragment cardFields on ProjectCard {
+  id
+  note
+  isArchived
+  content {
+    ...issueFields
+    ...pullRequestFields
+  }
+}
+` + contentFragments
+
+const contentFragments = `
+fragment issueFields on Issue {
+  id
+  author {
+    login
+  }
+  number
+  title
+  url
+  state
+  createdAt
+  updatedAt
+  closedAt
+  repository {
+    nameWithOwner
+  }
+  labels(first: 20) {
+    nodes {
+      name
+      color
+    }
+  }
+  milestone {
+    title
+  }
+  assignees(first: 10) {
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    edges {
+      node {
+        login
+      }
+    }
+  }
+}
+
+fragment pullRequestFields on PullRequest {
+  id
+  author {
+    login
+  }
+  number
+  title
+  url
+  state
+  isDraft
+  createdAt
+  updatedAt
+  closedAt
+  repository {
+    nameWithOwner
+  }
+  labels(first: 20) {
+    nodes {
+      name
+      color
+    }
+  }
+  milestone {
+    title
+  }
+}
+`
+
+func (c *Client) AssignIssue(ctx context.Context, user string, issue Content) error {
+	userID, err := c.getUserID(ctx, user)
 	if err != nil {
 		return err
 	}
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`mutation assignUser($userid: ID! $assignableid: ID!) {
+	req := c.newRequest(`mutation assignUser($userid: ID! $assignableid: ID!) {
 		addAssigneesToAssignable(input: {clientMutationId: "proj", assignableId: $assignableid, assigneeIds: [$userid]}) {
 				clientMutationId
 			}
 	}`)
 	req.Var("userid", userID)
 	req.Var("assignableid", issue.ID)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
 
 	res := struct{}{}
-	err = client.Run(ctx, req, &res)
+	err = c.mutate(ctx, req, &res)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UnassignIssue(ctx context.Context, user string, issue Content) error {
-	userID, err := getUserID(ctx, user)
+func (c *Client) UnassignIssue(ctx context.Context, user string, issue Content) error {
+	userID, err := c.getUserID(ctx, user)
 	if err != nil {
 		return err
 	}
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`mutation unassignUser($userid: ID! $assignableid: ID!) {
+	req := c.newRequest(`mutation unassignUser($userid: ID! $assignableid: ID!) {
 		removeAssigneesFromAssignable(input: {clientMutationId: "proj", assignableId: $assignableid, assigneeIds: [$userid]}) {
 				clientMutationId
 			}
 	}`)
 	req.Var("userid", userID)
 	req.Var("assignableid", issue.ID)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
 
 	res := struct{}{}
-	err = client.Run(ctx, req, &res)
+	err = c.mutate(ctx, req, &res)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func CloseIssue(ctx context.Context, issue Content) error {
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`mutation closeIssue($issueid: String!) {
+func (c *Client) CloseIssue(ctx context.Context, issue Content) error {
+	req := c.newRequest(`mutation closeIssue($issueid: String!) {
 			closeIssue(input: {clientMutationId: "proj", issueId: $issueid}) {
 				clientMutationId
 			}
 	}`)
 	req.Var("issueid", issue.ID)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
 
 	res := struct{}{}
-	err := client.Run(ctx, req, &res)
+	err := c.mutate(ctx, req, &res)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func ReopenIssue(ctx context.Context, issue Content) error {
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`mutation reopenIssue($issueid: String!) {
+func (c *Client) ReopenIssue(ctx context.Context, issue Content) error {
+	req := c.newRequest(`mutation reopenIssue($issueid: String!) {
 			reopenIssue(input: {clientMutationId: "proj", issueId: $issueid}) {
 				clientMutationId
 			}
 	}`)
 	req.Var("issueid", issue.ID)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
 
 	res := struct{}{}
-	err := client.Run(ctx, req, &res)
+	err := c.mutate(ctx, req, &res)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func MoveCard(ctx context.Context, issue Content, projectID int, colName string) error {
-	proj, err := GetProject(ctx, projectID)
-	if err != nil {
-		return err
+// UpdateIssue sets the title and body of an issue or pull request, leaving
+// either as it is if nil.
+func (c *Client) UpdateIssue(ctx context.Context, issue Content, title, body *string) error {
+	mutation, input := "updateIssue", map[string]interface{}{"id": issue.ID}
+	if strings.Contains(issue.URL, "pull") {
+		mutation, input = "updatePullRequest", map[string]interface{}{"pullRequestId": issue.ID}
 	}
-	var (
-		colID  string
-		cardID string
-	)
-	for _, col := range proj.Organization.Project.Columns.Nodes {
-		if strings.ToLower(colName) == strings.ToLower(col.Name) ||
-			strings.ToLower(strings.Replace(col.Name, " ", "", -1)) == strings.ToLower(colName) {
-			colID = col.ID
+	if title != nil {
+		input["title"] = *title
+	}
+	if body != nil {
+		input["body"] = *body
+	}
+	req := c.newRequest(fmt.Sprintf(`mutation editIssue($input: %sInput!) {
+		%s(input: $input) {
+			clientMutationId
 		}
-		for _, card := range col.Cards.Nodes {
-			if card.Content.Number == issue.Number {
-				cardID = card.ID
-			}
+	}`, strings.ToUpper(mutation[:1])+mutation[1:], mutation))
+	input["clientMutationId"] = "proj"
+	req.Var("input", input)
+
+	res := struct{}{}
+	return c.mutate(ctx, req, &res)
+}
+
+// NewIssue describes an issue for CreateIssue.
+type NewIssue struct {
+	// Repo is the repository to create the issue in, as owner/name.
+	Repo      string
+	Column    string
+	Title     string
+	Body      string
+	Assignees []string
+	Labels    []string
+}
+
+// CreateIssue files a new issue and adds it to the project in the column
+// named by issue.Column, or the first column if that is empty.
+func (c *Client) CreateIssue(ctx context.Context, ref ProjectRef, issue NewIssue) (Content, error) {
+	if issue.Title == "" {
+		return Content{}, fmt.Errorf("an issue needs a title")
+	}
+	repoID, err := c.getRepositoryID(ctx, issue.Repo)
+	if err != nil {
+		return Content{}, err
+	}
+	var assigneeIDs, labelIDs []string
+	for _, login := range issue.Assignees {
+		id, err := c.getUserID(ctx, login)
+		if err != nil {
+			return Content{}, err
 		}
+		assigneeIDs = append(assigneeIDs, id)
 	}
-	if colID == "" || cardID == "" {
-		return fmt.Errorf("couldn't move card: cardid: %s colid: %s", cardID, colID)
+	for _, name := range issue.Labels {
+		id, err := c.getLabelID(ctx, issue.Repo, name)
+		if err != nil {
+			return Content{}, err
+		}
+		labelIDs = append(labelIDs, id)
 	}
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`mutation moveCard($cardid: ID!, $colid: ID!) {
-			moveProjectCard(input: {cardId: $cardid, columnId: $colid}) {
-				clientMutationId
+	req := c.newRequest(`mutation createIssue($repoid: ID!, $title: String!, $body: String, $assigneeids: [ID!], $labelids: [ID!]) {
+		createIssue(input: {clientMutationId: "proj", repositoryId: $repoid, title: $title, body: $body, assigneeIds: $assigneeids, labelIds: $labelids}) {
+			issue {
+				id
+				number
+				url
 			}
+		}
 	}`)
-	req.Var("colid", colID)
-	req.Var("cardid", cardID)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
+	req.Var("repoid", repoID)
+	req.Var("title", issue.Title)
+	req.Var("body", issue.Body)
+	req.Var("assigneeids", assigneeIDs)
+	req.Var("labelids", labelIDs)
 
-	res := struct{}{}
-	err = client.Run(ctx, req, &res)
-	if err != nil {
-		return err
+	res := struct {
+		CreateIssue struct {
+			Issue Content `json:"issue"`
+		} `json:"createIssue"`
+	}{}
+	if err := c.mutate(ctx, req, &res); err != nil {
+		return Content{}, err
 	}
-	return nil
+	created := res.CreateIssue.Issue
+	if err := c.AddCard(ctx, ref, created.ID, issue.Column); err != nil {
+		return created, fmt.Errorf("created %s but couldn't add it to the project: %v", created.URL, err)
+	}
+	return created, nil
+}
+
+func matchColumn(name, colName string) bool {
+	return strings.ToLower(colName) == strings.ToLower(name) ||
+		strings.ToLower(strings.Replace(name, " ", "", -1)) == strings.ToLower(colName)
 }
 
-func getUserID(ctx context.Context, user string) (string, error) {
-	client := graphql.NewClient("https://api.github.com/graphql")
-	req := graphql.NewRequest(`query getUserID($login: String!){
+func (c *Client) getUserID(ctx context.Context, user string) (string, error) {
+	req := c.newRequest(`query getUserID($login: String!){
 		user(login: $login) {
 			id
 		}
 	}`)
 	req.Var("login", user)
-	req.Header.Set("Authorization", "Bearer "+os.Getenv("GITHUB_TOKEN"))
 
 	res := struct {
 		User struct {
 			ID string `json:"id"`
 		} `json:"user"`
 	}{}
-	err := client.Run(ctx, req, &res)
+	err := c.query(ctx, req, &res)
 	if err != nil {
 		return "", err
 	}
-
+	if res.User.ID == "" {
+		return "", fmt.Errorf("couldn't find user %s", user)
+	}
 	return res.User.ID, nil
 }
+
+func (c *Client) getRepositoryID(ctx context.Context, repo string) (string, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	req := c.newRequest(`query getRepositoryID($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) {
+			id
+		}
+	}`)
+	req.Var("owner", parts[0])
+	req.Var("name", parts[1])
+
+	res := struct {
+		Repository *struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return "", err
+	}
+	if res.Repository == nil {
+		return "", fmt.Errorf("couldn't find repository %s", repo)
+	}
+	return res.Repository.ID, nil
+}
+
+func (c *Client) getLabelID(ctx context.Context, repo, name string) (string, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	req := c.newRequest(`query getLabelID($owner: String!, $name: String!, $label: String!) {
+		repository(owner: $owner, name: $name) {
+			label(name: $label) {
+				id
+			}
+		}
+	}`)
+	req.Var("owner", parts[0])
+	req.Var("name", parts[1])
+	req.Var("label", name)
+
+	res := struct {
+		Repository *struct {
+			Label *struct {
+				ID string `json:"id"`
+			} `json:"label"`
+		} `json:"repository"`
+	}{}
+	if err := c.query(ctx, req, &res); err != nil {
+		return "", err
+	}
+	if res.Repository == nil || res.Repository.Label == nil {
+		return "", fmt.Errorf("couldn't find label %q in %s", name, repo)
+	}
+	return res.Repository.Label.ID, nil
+}
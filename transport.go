@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type idempotentKey struct{}
+
+// withIdempotent marks requests made with ctx as safe to retry after a server
+// error, as opposed to only after being rejected by a rate limit.
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+func isIdempotent(ctx context.Context) bool {
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
+
+type errorsKey struct{}
+
+// graphQLError is an error in a GraphQL response, with the type and path
+// that the graphql package leaves out of its errors.
+type graphQLError struct {
+	Type    string        `json:"type"`
+	Path    []interface{} `json:"path"`
+	Message string        `json:"message"`
+}
+
+// withErrors has the errors in the responses to requests made with ctx
+// stored in errs.
+func withErrors(ctx context.Context, errs *[]graphQLError) context.Context {
+	return context.WithValue(ctx, errorsKey{}, errs)
+}
+
+// retryTransport retries requests that failed transiently. Queries are
+// retried after network errors and 5xx responses with exponential backoff;
+// any request rejected by a primary or secondary rate limit is retried once
+// the limit resets, as long as that is within maxWait.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxWait    time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:       base,
+		maxRetries: 4,
+		baseDelay:  500 * time.Millisecond,
+		maxDelay:   15 * time.Second,
+		maxWait:    time.Minute,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.roundTrip(req)
+	if errs, ok := req.Context().Value(errorsKey{}).(*[]graphQLError); ok && err == nil {
+		err = readErrors(res, errs)
+	}
+	return res, err
+}
+
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	idempotent := isIdempotent(ctx)
+	replayable := req.Body == nil || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		res, err := t.base.RoundTrip(r)
+		if attempt == t.maxRetries || !replayable {
+			return res, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if !idempotent || ctx.Err() != nil {
+				return nil, err
+			}
+			wait = t.backoff(attempt)
+		case res.StatusCode == http.StatusBadGateway ||
+			res.StatusCode == http.StatusServiceUnavailable ||
+			res.StatusCode == http.StatusGatewayTimeout:
+			if !idempotent {
+				return res, nil
+			}
+			wait = retryAfter(res)
+			if wait == 0 {
+				wait = t.backoff(attempt)
+			}
+		default:
+			limited, err := isRateLimited(res)
+			if err != nil {
+				return nil, err
+			}
+			if !limited {
+				return res, nil
+			}
+			wait = retryAfter(res)
+			if wait == 0 {
+				wait = rateLimitReset(res)
+			}
+			if wait == 0 {
+				wait = t.backoff(attempt)
+			}
+			if wait > t.maxWait {
+				return res, nil
+			}
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.baseDelay << uint(attempt)
+	if d > t.maxDelay {
+		d = t.maxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// isRateLimited reports whether GitHub rejected the request for exceeding a
+// rate limit. GraphQL reports the primary limit in a 200 response, so the
+// body is inspected and replaced when the budget is exhausted.
+func isRateLimited(res *http.Response) (bool, error) {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, nil
+	case http.StatusForbidden:
+		return res.Header.Get("Retry-After") != "" || res.Header.Get("X-RateLimit-Remaining") == "0", nil
+	case http.StatusOK:
+		if res.Header.Get("X-RateLimit-Remaining") != "0" {
+			return false, nil
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return false, err
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return bytes.Contains(body, []byte(`"RATE_LIMITED"`)), nil
+	}
+	return false, nil
+}
+
+// readErrors stores the errors in the body of res in errs, replacing the
+// body so it can still be decoded.
+func readErrors(res *http.Response, errs *[]graphQLError) error {
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var payload struct {
+		Errors []graphQLError `json:"errors"`
+	}
+	// a body that isn't JSON is reported by the graphql package
+	json.Unmarshal(body, &payload)
+	*errs = payload.Errors
+	return nil
+}
+
+func retryAfter(res *http.Response) time.Duration {
+	secs, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func rateLimitReset(res *http.Response) time.Duration {
+	if res.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0
+	}
+	reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	d := time.Until(time.Unix(reset, 0))
+	if d < 0 {
+		return 0
+	}
+	return d + time.Second
+}
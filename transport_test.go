@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+func TestRetryTransport(t *testing.T) {
+	var (
+		ok          = fakeResponse{status: 200, body: `{"data":{}}`}
+		badGateway  = fakeResponse{status: 502}
+		rateLimited = fakeResponse{
+			status:  200,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "0"},
+			body:    `{"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`,
+		}
+		secondaryLimit = fakeResponse{status: 403, headers: map[string]string{"Retry-After": "0"}}
+		longLimit      = fakeResponse{status: 429, headers: map[string]string{"Retry-After": "3600"}}
+	)
+	tests := []struct {
+		name       string
+		idempotent bool
+		responses  []fakeResponse
+		wantStatus int
+		wantBody   string
+		wantTries  int
+	}{
+		{"success", true, []fakeResponse{ok}, 200, ok.body, 1},
+		{"query after server error", true, []fakeResponse{badGateway, badGateway, ok}, 200, ok.body, 3},
+		{"mutation after server error", false, []fakeResponse{badGateway, ok}, 502, "", 1},
+		{"primary rate limit", false, []fakeResponse{rateLimited, ok}, 200, ok.body, 2},
+		{"secondary rate limit", false, []fakeResponse{secondaryLimit, ok}, 200, ok.body, 2},
+		{"limit resets too late", true, []fakeResponse{longLimit, ok}, 429, "", 1},
+		{"gives up", true, []fakeResponse{badGateway, badGateway, badGateway, badGateway}, 502, "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tries := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != "query" {
+					t.Errorf("try %d sent body %q, want %q", tries+1, body, "query")
+				}
+				res := tt.responses[tries]
+				tries++
+				for k, v := range res.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(res.status)
+				fmt.Fprint(w, res.body)
+			}))
+			defer srv.Close()
+
+			rt := newRetryTransport(nil)
+			rt.maxRetries = 2
+			rt.baseDelay = time.Millisecond
+			rt.maxDelay = time.Millisecond
+			ctx := context.Background()
+			if tt.idempotent {
+				ctx = withIdempotent(ctx)
+			}
+			req, err := http.NewRequestWithContext(ctx, "POST", srv.URL, strings.NewReader("query"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, _ := ioutil.ReadAll(res.Body)
+			if res.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", res.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+			if tries != tt.wantTries {
+				t.Errorf("made %d tries, want %d", tries, tt.wantTries)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	rt := newRetryTransport(nil)
+	rt.baseDelay = time.Hour
+	rt.maxDelay = time.Hour
+	ctx, cancel := context.WithTimeout(withIdempotent(context.Background()), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", srv.URL, strings.NewReader("query"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err != context.DeadlineExceeded {
+		t.Errorf("RoundTrip() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
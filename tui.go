@@ -1,10 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,220 +15,1135 @@ import (
 	"github.com/rivo/tview"
 )
 
-func doTUI(ctx context.Context, id int) {
-	app := tview.NewApplication()
-	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+type tui struct {
+	ctx    context.Context
+	client *Client
+	ref    ProjectRef
+	log    *log.Logger
+
+	app        *tview.Application
+	table      *tview.Table
+	flex       *tview.Flex
+	textbox    *tview.TextView
+	inputField *tview.InputField
+	message    *tview.TextView
+	status     *tview.TextView
+
+	res    *ProjectQueryResponse
+	filter *Filter
+	fields []string
+	// rows holds the column and card drawn on each table row, with a nil
+	// card for column headers.
+	rows []tuiRow
+	// marked holds the IDs of the cards selected for the next command, and
+	// visualStart the row a visual selection started on, or -1.
+	marked      map[string]bool
+	visualStart int
+	// filtering is set while the input field is used to edit the filter,
+	// with prevFilter restored if the edit is cancelled.
+	filtering  bool
+	prevFilter *Filter
+	// detail is the issue open in textbox, see detail.go. searching is set
+	// while the input field is used to search it for search, which has
+	// searchMatches matches with searchMatch highlighted.
+	detail        *IssueDetail
+	searching     bool
+	search        string
+	searchMatch   int
+	searchMatches int
+	// commentID is the comment in the detail pane selected with [ and ],
+	// and drafts holds the text of comments that failed to post or save,
+	// by the ID of the issue or comment.
+	commentID string
+	drafts    map[string]string
+	// repoOptions caches the labels and milestones of repositories for
+	// completion, see labels.go.
+	repoOptions     map[string]repoOptions
+	fetchingOptions map[string]bool
+	// lastFailed is the last command that returned an error, run again by
+	// :retry.
+	lastFailed string
+	// resolved holds the cards and issue the running command acted on, and
+	// retryTargets those of lastFailed, so that :retry acts on them again
+	// rather than on whatever is selected by then.
+	resolved     *tuiTargets
+	retryTargets *tuiTargets
+	// pendingConfirm is run if the user answers y to the question in the
+	// message area.
+	pendingConfirm func() error
+
+	// kanban shows the columns side by side in board instead of in table,
+	// see board.go.
+	kanban bool
+	board  *tview.Flex
+	// boardCols are the lists of the columns on the board, boardCol the one
+	// with the selection, boardOffset the first one shown, and boardHeaders
+	// the rows index of each column.
+	boardCols    []*tview.Table
+	boardCol     int
+	boardOffset  int
+	boardHeaders []int
+	boardCells   map[int]*tview.TableCell
+}
+
+type tuiTargets struct {
+	cards    []Node
+	hasCards bool
+	issue    *Content
+}
+
+type tuiRow struct {
+	col  ColumnNode
+	card *Node
+}
+
+type tuiOptions struct {
+	Filter *Filter
+	// Fields are the optional columns shown after the URL.
+	Fields []string
+	// Kanban starts in the board layout rather than the list.
+	Kanban bool
+}
+
+func doTUI(ctx context.Context, client *Client, ref ProjectRef, opts tuiOptions) error {
+	t := &tui{
+		ctx:    ctx,
+		client: client,
+		ref:    ref,
+		log:    openLog(),
+		filter: opts.Filter,
+		fields: opts.Fields,
+		marked: make(map[string]bool),
+		drafts: make(map[string]string),
+		kanban: opts.Kanban,
+
+		repoOptions:     make(map[string]repoOptions),
+		fetchingOptions: make(map[string]bool),
+
+		visualStart: -1,
+	}
+
+	t.app = tview.NewApplication()
+	t.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
 		screen.Clear()
 		return false
 	})
-	table := tview.NewTable()
-	table.SetBackgroundColor(tcell.ColorDefault)
+	t.table = tview.NewTable()
+	t.table.SetBackgroundColor(tcell.ColorDefault)
 	selected := tcell.Style{}.Reverse(true)
 	//selected := tcell.Style{}.Background(tcell.ColorWhite).Foreground(tcell.ColorBlack)
-	table.SetSelectedStyle(selected)
-	issues, err := refreshTable(ctx, table, id)
-	if err != nil {
-		panic(err)
-	}
-	table.SetSelectable(true, false)
+	t.table.SetSelectedStyle(selected)
+	t.table.SetSelectable(true, false)
+	t.table.SetSelectedFunc(func(row, column int) {
+		t.showDetail(row)
+	})
+	t.table.SetSelectionChangedFunc(t.selectionChanged)
+	t.board = tview.NewFlex()
+	t.board.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+		t.scrollBoard(width)
+		return x, y, width, height
+	})
 
-	flex := tview.NewFlex()
-	flex.AddItem(table, 0, 3, true)
+	t.status = tview.NewTextView().SetTextAlign(tview.AlignRight)
+	t.status.SetBackgroundColor(tcell.ColorDefault)
+	t.status.SetTextColor(tcell.ColorGray)
 
-	textbox := tview.NewTextView()
-	textbox.Box.SetBorder(true)
-	textbox.SetBackgroundColor(tcell.ColorDefault)
+	t.message = tview.NewTextView().SetDynamicColors(true)
+	t.message.SetBackgroundColor(tcell.ColorDefault)
 
-	var focusIssue string
+	t.flex = tview.NewFlex()
+	t.flex.AddItem(t.layout(), 0, 3, true)
 
-	table.SetSelectedFunc(func(row int, column int) {
-		cell := table.GetCell(row, 3)
-		if cell.Text == "" {
-			table.Select(row+1, column)
+	t.textbox = tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWordWrap(true)
+	t.textbox.Box.SetBorder(true)
+	t.textbox.SetBackgroundColor(tcell.ColorDefault)
+
+	t.inputField = tview.NewInputField().SetFieldTextColor(tcell.ColorBlack)
+	t.inputField.SetChangedFunc(func(text string) {
+		if t.filtering {
+			t.setFilter(text)
 		}
-		resource := ""
-		if strings.Contains(cell.Text, "pull") {
-			resource = "pr"
-		} else {
-			resource = "issue"
-		}
-		buf := bytes.Buffer{}
-		errBuf := bytes.Buffer{}
-		cmd := exec.Command("gh", resource, "view", cell.Text)
-		cmd.Stdout = &buf
-		cmd.Stderr = &errBuf
-		err := cmd.Run()
-		if err != nil {
-			panic(err.Error() + errBuf.String())
-		}
-		textbox.SetText(buf.String())
-		if focusIssue == "" {
-			flex.AddItem(textbox, 0, 3, true)
-			focusIssue = table.GetCell(row, 0).Text
-			if err != nil {
-				panic(err)
-			}
+		if t.searching {
+			t.searchDetail(text)
 		}
-		textbox.ScrollToBeginning()
-		app.SetFocus(textbox)
 	})
-
-	inputField := tview.NewInputField().SetFieldTextColor(tcell.ColorBlack)
-	inputField.SetDoneFunc(func(key tcell.Key) {
-		defer app.SetFocus(table)
-		if key != tcell.KeyEnter {
+	t.inputField.SetAutocompleteFunc(t.complete)
+	t.inputField.SetDoneFunc(func(key tcell.Key) {
+		if t.searching {
+			t.searching = false
+			t.inputField.SetLabel("")
+			t.inputField.SetText("")
+			if key != tcell.KeyEnter {
+				t.searchDetail("")
+			}
+			t.app.SetFocus(t.textbox)
 			return
 		}
-		args := strings.Split(inputField.GetText(), " ")
-		if len(args) == 0 {
+		defer func() { t.app.SetFocus(t.view()) }()
+		if t.filtering {
+			t.filtering = false
+			t.inputField.SetLabel("")
+			t.inputField.SetText("")
+			if key != tcell.KeyEnter {
+				t.filter = t.prevFilter
+				t.render()
+			}
 			return
 		}
-		row, _ := table.GetSelection()
-		issue := table.GetCell(row, 0).Text
-		if focusIssue != "" {
-			issue = focusIssue
+		if key != tcell.KeyEnter {
+			return
 		}
-		switch args[0] {
-		case ":assign":
-			if len(args) >= 3 {
-				issue = args[2]
-			}
-			if _, ok := issues[issue]; !ok {
-				inputField.SetText(fmt.Sprintf("unsupported command on %s", issue))
-				return
-			}
-			inputField.SetText(fmt.Sprintf("assigning %s to %s", args[1], issue))
-			err := AssignIssue(context.Background(), args[1], issues[issue])
-			if err != nil {
-				panic(err)
+		line := t.inputField.GetText()
+		t.inputField.SetText("")
+		t.execute(line)
+	})
+
+	vstack := tview.NewFlex().SetDirection(tview.FlexRow)
+	vstack.AddItem(t.flex, 0, 1, true)
+	vstack.AddItem(t.message, 1, 0, false)
+	bottom := tview.NewFlex()
+	bottom.AddItem(t.inputField, 0, 3, false)
+	bottom.AddItem(t.status, 0, 1, false)
+	vstack.AddItem(bottom, 1, 0, false)
+
+	t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if t.pendingConfirm != nil {
+			fn := t.pendingConfirm
+			t.pendingConfirm = nil
+			if event.Rune() != 'y' {
+				t.setMessage("cancelled")
+				return nil
 			}
-			issues, err = refreshTable(ctx, table, id)
-			if err != nil {
-				panic(err)
+			if err := fn(); err != nil {
+				t.fail("", err)
 			}
-		case ":unassign":
-			if len(args) >= 3 {
-				issue = args[2]
+			return nil
+		}
+		// Esc in the input field cancels the search or filter being typed
+		if event.Key() == tcell.KeyEscape && t.detail != nil && t.app.GetFocus() != t.inputField {
+			t.closeDetail()
+			return nil
+		}
+		if t.boardFocused() && t.markKey(event) {
+			return nil
+		}
+		if t.boardFocused() && t.kanban && (event.Rune() == 'h' || event.Rune() == 'l') {
+			t.focusColumn(event.Rune() == 'l')
+			return nil
+		}
+		if t.boardFocused() && event.Rune() == 'b' {
+			t.toggleLayout()
+			return nil
+		}
+		if t.boardFocused() && (event.Rune() == 'J' || event.Rune() == 'K') {
+			if err := t.shiftCard(event.Rune() == 'J'); err != nil {
+				t.fail("", err)
 			}
-			if _, ok := issues[issue]; !ok {
-				inputField.SetText(fmt.Sprintf("unsupported command on %s", issue))
-				return
+			return nil
+		}
+		if t.app.GetFocus() == t.textbox {
+			switch event.Rune() {
+			case '/':
+				t.searching = true
+				t.inputField.SetLabel("search: ")
+				t.inputField.SetText("")
+				t.app.SetFocus(t.inputField)
+				return nil
+			case 'n', 'N':
+				t.nextMatch(event.Rune() == 'n')
+				return nil
+			case '[', ']':
+				t.selectComment(event.Rune() == ']')
+				return nil
 			}
-			inputField.SetText(fmt.Sprintf("removing %s from %s", args[1], issue))
-			err := UnassignIssue(context.Background(), args[1], issues[issue])
-			if err != nil {
-				panic(err)
+		}
+		if event.Rune() == ':' && t.app.GetFocus() != t.inputField {
+			t.inputField.SetText(":")
+			t.app.SetFocus(t.inputField)
+			return nil
+		}
+		if event.Rune() == '/' && t.app.GetFocus() != t.inputField {
+			t.filtering = true
+			t.prevFilter = t.filter
+			t.inputField.SetLabel("/")
+			t.inputField.SetText(t.filter.String())
+			t.app.SetFocus(t.inputField)
+			return nil
+		}
+		return event
+	})
+
+	if err := t.refresh(); err != nil {
+		t.fail(":refresh", err)
+	}
+	return t.app.SetRoot(vstack, true).EnableMouse(true).Run()
+}
+
+// execute runs a command line entered in the input field. Failures are
+// reported in the message area rather than ending the session.
+func (t *tui) execute(line string) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return
+	}
+	t.resolved = &tuiTargets{}
+	if args[0] == ":retry" {
+		if t.lastFailed == "" {
+			t.setMessage("nothing to retry")
+			return
+		}
+		line, args = t.lastFailed, strings.Fields(t.lastFailed)
+		t.resolved = t.retryTargets
+	}
+	err := t.runCommand(args)
+	resolved := t.resolved
+	t.resolved = nil
+	if err != nil {
+		t.fail(line, err)
+		t.retryTargets = resolved
+		return
+	}
+	t.lastFailed = ""
+	t.retryTargets = nil
+}
+
+func (t *tui) runCommand(args []string) error {
+	switch args[0] {
+	case ":assign", ":unassign":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: %s <user> [issue...]", args[0])
+		}
+		issues := issuesOf(t.targets(args[2:]), false)
+		if len(issues) == 0 {
+			t.unsupported(args[2:])
+			return nil
+		}
+		if args[0] == ":assign" {
+			t.setMessage(fmt.Sprintf("assigning %s to %s", args[1], describeIssues(issues)))
+			if err := t.client.AssignIssues(t.ctx, args[1], issues); err != nil {
+				return err
 			}
-			issues, err = refreshTable(ctx, table, id)
-			if err != nil {
-				panic(err)
+		} else {
+			t.setMessage(fmt.Sprintf("removing %s from %s", args[1], describeIssues(issues)))
+			if err := t.client.UnassignIssues(t.ctx, args[1], issues); err != nil {
+				return err
 			}
-		case ":close":
-			if len(args) >= 2 {
-				issue = args[1]
+		}
+		t.clearMarks()
+		return t.refresh()
+	case ":close", ":reopen":
+		issues := issuesOf(t.targets(args[1:]), true)
+		if len(issues) == 0 {
+			t.unsupported(args[1:])
+			return nil
+		}
+		if args[0] == ":close" {
+			t.setMessage(fmt.Sprintf("closing %s", describeIssues(issues)))
+			if err := t.client.CloseIssues(t.ctx, issues); err != nil {
+				return err
 			}
-			if iss, ok := issues[issue]; !ok || strings.Contains(iss.URL, "pull") {
-				inputField.SetText(fmt.Sprintf("unsupported command on %s", issue))
-				return
+		} else {
+			t.setMessage(fmt.Sprintf("reopening %s", describeIssues(issues)))
+			if err := t.client.ReopenIssues(t.ctx, issues); err != nil {
+				return err
 			}
-			inputField.SetText(fmt.Sprintf("closing %s", issue))
-			err := CloseIssue(context.Background(), issues[issue])
-			if err != nil {
-				panic(err)
+		}
+		t.clearMarks()
+		// wait for github automation to move stuff around
+		time.Sleep(500 * time.Millisecond)
+		return t.refresh()
+	case ":label":
+		return t.labelCommand(args[1:])
+	case ":milestone":
+		return t.milestoneCommand(args[1:])
+	case ":move":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :move <column> [issue...]")
+		}
+		cards := t.targets(args[2:])
+		if len(cards) == 0 {
+			t.unsupported(args[2:])
+			return nil
+		}
+		t.setMessage(fmt.Sprintf("moving %d card(s) to %s", len(cards), args[1]))
+		if err := t.client.MoveCards(t.ctx, t.ref, cards, args[1]); err != nil {
+			return err
+		}
+		t.clearMarks()
+		return t.refresh()
+	case ":archive", ":unarchive":
+		cards := t.targets(args[1:])
+		if len(cards) == 0 {
+			t.unsupported(args[1:])
+			return nil
+		}
+		archive := args[0] == ":archive"
+		if archive == t.ref.Archived {
+			t.setMessage(fmt.Sprintf("nothing to %s here, :archived switches between active and archived cards", args[0][1:]))
+			return nil
+		}
+		if archive {
+			t.setMessage(fmt.Sprintf("archiving %d card(s)", len(cards)))
+		} else {
+			t.setMessage(fmt.Sprintf("restoring %d card(s)", len(cards)))
+		}
+		if err := t.client.ArchiveCards(t.ctx, t.ref, cards, archive); err != nil {
+			return err
+		}
+		t.clearMarks()
+		return t.refresh()
+	case ":board":
+		t.toggleLayout()
+		return nil
+	case ":archived":
+		t.ref.Archived = !t.ref.Archived
+		t.clearMarks()
+		return t.refresh()
+	case ":comment":
+		return t.commentCommand(args[1:])
+	case ":column":
+		return t.columnCommand(args[1:])
+	case ":note":
+		return t.noteCommand(args[1:])
+	case ":edit":
+		return t.editIssue()
+	case ":new":
+		return t.newIssue()
+	case ":add":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :add <issue> [column]")
+		}
+		colName := strings.Join(args[2:], " ")
+		if sel := t.selectedRow(); colName == "" && sel >= 0 {
+			colName = t.rows[sel].col.Name
+		}
+		repo, number, err := parseIssueRef(args[1], t.ref.Repo())
+		if err != nil {
+			return err
+		}
+		content, err := t.client.GetIssue(t.ctx, repo, number)
+		if err != nil {
+			return err
+		}
+		t.setMessage(fmt.Sprintf("adding %s to %s", content.URL, colName))
+		if err := t.client.AddCard(t.ctx, t.ref, content.ID, colName); err != nil {
+			return err
+		}
+		return t.refresh()
+	case ":remove":
+		archive := len(args) >= 2 && args[1] == "--archive"
+		if archive {
+			args = args[1:]
+		}
+		var card *Node
+		if len(args) >= 2 {
+			c, ok := t.findIssueCard(args[1])
+			if !ok {
+				t.setMessage(fmt.Sprintf("no card for %s on the board", args[1]))
+				return nil
 			}
-			// wait for github automation to move stuff around
-			time.Sleep(500 * time.Millisecond)
-			issues, err = refreshTable(ctx, table, id)
-			if err != nil {
-				panic(err)
+			card = &c
+		} else if sel := t.selectedRow(); sel >= 0 {
+			card = t.rows[sel].card
+		}
+		if card == nil {
+			t.setMessage("no card selected")
+			return nil
+		}
+		if archive {
+			t.setMessage("archiving card")
+			if err := t.client.ArchiveCard(t.ctx, t.ref, *card, true); err != nil {
+				return err
 			}
-		case ":reopen":
-			if len(args) >= 2 {
-				issue = args[1]
+			return t.refresh()
+		}
+		name := "the note"
+		if card.Content.Number != 0 {
+			name = fmt.Sprintf("#%d", card.Content.Number)
+		}
+		// unlike archiving, deleting can't be undone
+		remove := *card
+		t.confirm(fmt.Sprintf("remove %s from the board?", name), func() error {
+			t.setMessage("removing card")
+			if err := t.client.DeleteCard(t.ctx, t.ref, remove); err != nil {
+				return err
 			}
-			inputField.SetText(fmt.Sprintf("closing %s", issue))
-			if iss, ok := issues[issue]; !ok || strings.Contains(iss.URL, "pull") {
-				inputField.SetText(fmt.Sprintf("unsupported command on %s", issue))
-				return
+			return t.refresh()
+		})
+		return nil
+	case ":filter":
+		f, err := ParseFilter(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		t.filter = f
+		t.render()
+		return nil
+	case ":show":
+		fields, err := parseFields(strings.Join(args[1:], ","))
+		if err != nil {
+			return err
+		}
+		t.fields = fields
+		t.render()
+		return nil
+	case ":refresh":
+		return t.refresh()
+	case ":q":
+		if t.detail != nil {
+			t.closeDetail()
+			return nil
+		}
+		t.app.Stop()
+		return nil
+	}
+	t.setMessage(fmt.Sprintf("unknown command %s", args[0]))
+	return nil
+}
+
+// targets returns the cards a command acts on: the issues numbered in args
+// if there are any, else the marked cards, else the card open in the detail
+// pane or selected in the table.
+func (t *tui) targets(args []string) []Node {
+	if t.resolved != nil && t.resolved.hasCards {
+		return t.resolved.cards
+	}
+	cards := t.resolveTargets(args)
+	if t.resolved != nil {
+		t.resolved.cards, t.resolved.hasCards = cards, true
+	}
+	return cards
+}
+
+func (t *tui) resolveTargets(args []string) []Node {
+	var cards []Node
+	if len(args) > 0 {
+		for _, arg := range args {
+			if card, ok := t.findIssueCard(arg); ok {
+				cards = append(cards, card)
 			}
-			err := ReopenIssue(context.Background(), issues[issue])
-			if err != nil {
-				panic(err)
+		}
+		return cards
+	}
+	if len(t.marked) > 0 {
+		for _, row := range t.rows {
+			if row.card != nil && t.marked[row.card.ID] {
+				cards = append(cards, *row.card)
 			}
-			// wait for github automation to move stuff around
-			time.Sleep(500 * time.Millisecond)
-			issues, err = refreshTable(ctx, table, id)
-			if err != nil {
-				panic(err)
+		}
+		return cards
+	}
+	if t.detail != nil {
+		if card, ok := findCard(t.res, t.detail.Content); ok {
+			cards = append(cards, card)
+		}
+		return cards
+	}
+	if row := t.selectedRow(); row >= 0 && t.rows[row].card != nil {
+		cards = append(cards, *t.rows[row].card)
+	}
+	return cards
+}
+
+// unsupported reports that a command can't act on the cards it targets,
+// naming them as targets found them.
+func (t *tui) unsupported(args []string) {
+	cards := t.targets(args)
+	var what string
+	switch {
+	case len(cards) == 0 && len(args) > 0:
+		t.setMessage(fmt.Sprintf("no card for %s on the board", strings.Join(args, " ")))
+		return
+	case len(cards) == 0:
+		t.setMessage("no card selected")
+		return
+	case len(cards) > 1:
+		what = fmt.Sprintf("%d cards", len(cards))
+	case cards[0].Content.Number == 0:
+		what = "a note"
+	default:
+		what = fmt.Sprintf("#%d", cards[0].Content.Number)
+	}
+	t.setMessage("unsupported command on " + what)
+}
+
+// findIssueCard finds the card of an issue given as for the CLI commands.
+// A bare number only matches if a single repository on the board has it.
+func (t *tui) findIssueCard(arg string) (Node, bool) {
+	repo, number, err := parseIssueRef(arg, t.ref.Repo())
+	if err != nil {
+		if number, err = strconv.Atoi(strings.TrimPrefix(arg, "#")); err != nil {
+			return Node{}, false
+		}
+		repo = ""
+	}
+	var found []Node
+	for _, col := range t.res.Owner.Project.Columns.Nodes {
+		for _, card := range col.Cards.Nodes {
+			if card.Content.Number == number && (repo == "" || strings.EqualFold(card.Content.Repository.NameWithOwner, repo)) {
+				found = append(found, card)
 			}
-		case ":move":
-			if len(args) >= 3 {
-				issue = args[2]
+		}
+	}
+	if len(found) != 1 {
+		return Node{}, false
+	}
+	return found[0], true
+}
+
+// issuesOf returns the issues and pull requests on cards, leaving out notes,
+// and pull requests too if issuesOnly is set.
+func issuesOf(cards []Node, issuesOnly bool) []Content {
+	var issues []Content
+	for _, card := range cards {
+		if card.Content.Number == 0 || issuesOnly && strings.Contains(card.Content.URL, "pull") {
+			continue
+		}
+		issues = append(issues, card.Content)
+	}
+	return issues
+}
+
+func describeIssues(issues []Content) string {
+	if len(issues) == 1 {
+		return fmt.Sprintf("#%d", issues[0].Number)
+	}
+	return fmt.Sprintf("%d issues", len(issues))
+}
+
+// markKey handles the keys for marking cards: space toggles the selected
+// card, v starts and ends a visual range, a marks the selected column, A
+// marks every card shown, and Esc clears the marks.
+func (t *tui) markKey(event *tcell.EventKey) bool {
+	row := t.selectedRow()
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		if len(t.marked) == 0 && t.visualStart < 0 {
+			return false
+		}
+		t.clearMarks()
+	case event.Rune() == ' ':
+		if row >= 0 && t.rows[row].card != nil {
+			id := t.rows[row].card.ID
+			if t.marked[id] {
+				delete(t.marked, id)
+			} else {
+				t.marked[id] = true
 			}
-			colName := args[1]
-			if iss, ok := issues[issue]; !ok || strings.Contains(iss.URL, "pull") {
-				inputField.SetText(fmt.Sprintf("unsupported command on %s", issue))
-				return
+		}
+		if row+1 < len(t.rows) && (!t.kanban || t.rows[row+1].card != nil) {
+			t.selectRow(row + 1)
+		}
+	case event.Rune() == 'v':
+		if row < 0 {
+			return true
+		}
+		if t.visualStart < 0 {
+			t.visualStart = row
+			t.setMessage("-- VISUAL -- v to mark the range, Esc to cancel")
+			break
+		}
+		from, to := t.visualStart, row
+		if from > to {
+			from, to = to, from
+		}
+		for r := from; r <= to && r < len(t.rows); r++ {
+			if t.rows[r].card != nil {
+				t.marked[t.rows[r].card.ID] = true
 			}
-			inputField.SetText(fmt.Sprintf("moving %s", issue))
-			err := MoveCard(context.Background(), issues[issue], id, colName)
-			if err != nil {
-				panic(err)
+		}
+		t.visualStart = -1
+		t.setMessage("")
+	case event.Rune() == 'a':
+		if row < 0 {
+			return true
+		}
+		for _, r := range t.rows {
+			if r.card != nil && r.col.Name == t.rows[row].col.Name {
+				t.marked[r.card.ID] = true
 			}
-			issues, err = refreshTable(ctx, table, id)
-			if err != nil {
-				panic(err)
+		}
+	case event.Rune() == 'A':
+		for _, r := range t.rows {
+			if r.card != nil {
+				t.marked[r.card.ID] = true
 			}
-		case ":q":
-			if focusIssue != "" {
-				flex.RemoveItem(textbox)
-				return
+		}
+	default:
+		return false
+	}
+	t.paintMarks()
+	return true
+}
+
+func (t *tui) clearMarks() {
+	t.marked = make(map[string]bool)
+	t.visualStart = -1
+	t.paintMarks()
+}
+
+// paintMarks highlights the marked cards and the visual range.
+func (t *tui) paintMarks() {
+	from, to := t.visualStart, t.selectedRow()
+	if from > to {
+		from, to = to, from
+	}
+	for r, row := range t.rows {
+		if row.card == nil {
+			continue
+		}
+		bg := tcell.ColorDefault
+		if t.marked[row.card.ID] || t.visualStart >= 0 && r >= from && r <= to {
+			bg = tcell.ColorDarkSlateGray
+		}
+		if t.kanban {
+			if cell := t.boardCells[r]; cell != nil {
+				cell.SetBackgroundColor(bg)
 			}
-			app.Stop()
+			continue
 		}
-	})
+		for c := 0; c < t.table.GetColumnCount(); c++ {
+			t.table.GetCell(r, c).SetBackgroundColor(bg)
+		}
+	}
+	t.updateStatus()
+}
 
-	vstack := tview.NewFlex().SetDirection(tview.FlexRow)
-	vstack.AddItem(flex, 0, 1000, true)
-	vstack.AddItem(inputField, 0, 1, false)
-
-	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if event.Key() == tcell.KeyEscape && focusIssue != "" {
-			flex.RemoveItem(textbox)
-			app.SetFocus(table)
-			focusIssue = ""
+// updateStatus shows the number of marked cards, the filter and the rate
+// limit in the status bar.
+func (t *tui) updateStatus() {
+	var parts []string
+	if t.ref.Archived {
+		parts = append(parts, "archived")
+	}
+	if len(t.marked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", len(t.marked)))
+	}
+	if !t.filter.Empty() {
+		parts = append(parts, "/"+t.filter.String())
+	}
+	if t.res != nil {
+		parts = append(parts, t.res.RateLimit.String())
+	}
+	t.status.SetText(strings.Join(parts, "  "))
+}
+
+// shiftCard moves the selected card past the card shown below or above it
+// in its column, skipping over any the filter hides.
+func (t *tui) shiftCard(down bool) error {
+	row := t.selectedRow()
+	if row < 0 || t.rows[row].card == nil {
+		return nil
+	}
+	card := *t.rows[row].card
+	var pos CardPosition
+	if down {
+		if row+1 >= len(t.rows) || t.rows[row+1].card == nil {
 			return nil
 		}
-		if event.Rune() == ':' {
-			inputField.SetText(":")
-			app.SetFocus(inputField)
+		pos.After = t.rows[row+1].card.ID
+	} else {
+		if t.rows[row-1].card == nil {
 			return nil
 		}
-		return event
+		// positions are relative to the full column, so go after the card
+		// before the one shown above
+		col, i, ok := locateCard(t.res, t.rows[row-1].card.ID)
+		if !ok {
+			return nil
+		}
+		if i > 0 {
+			pos.After = col.Cards.Nodes[i-1].ID
+		}
+	}
+	t.setMessage("moving card")
+	if err := t.client.RepositionCard(t.ctx, t.ref, card, pos); err != nil {
+		return err
+	}
+	if err := t.refresh(); err != nil {
+		return err
+	}
+	t.setMessage("")
+	for r, row := range t.rows {
+		if row.card != nil && row.card.ID == card.ID {
+			t.selectRow(r)
+		}
+	}
+	return nil
+}
+
+// columnCommand runs :column add, rename, move and delete, acting on the
+// column of the selection.
+func (t *tui) columnCommand(args []string) error {
+	usage := fmt.Errorf("usage: :column add <name> | rename <name> | move left|right|first | delete")
+	if len(args) == 0 {
+		return usage
+	}
+	if args[0] == "add" {
+		if len(args) < 2 {
+			return usage
+		}
+		name := strings.Join(args[1:], " ")
+		t.setMessage(fmt.Sprintf("adding column %s", name))
+		if err := t.client.AddColumn(t.ctx, t.ref, name); err != nil {
+			return err
+		}
+		return t.refresh()
+	}
+	row := t.selectedRow()
+	if row < 0 {
+		return fmt.Errorf("no column selected")
+	}
+	// rows hold filtered columns, so count cards on the full board
+	col, err := findColumn(t.res, t.rows[row].col.Name)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "rename":
+		if len(args) < 2 {
+			return usage
+		}
+		name := strings.Join(args[1:], " ")
+		t.setMessage(fmt.Sprintf("renaming %s to %s", col.Name, name))
+		if err := t.client.RenameColumn(t.ctx, t.ref, col.Name, name); err != nil {
+			return err
+		}
+	case "move":
+		if len(args) != 2 {
+			return usage
+		}
+		cols := t.res.Owner.Project.Columns.Nodes
+		i := 0
+		for i < len(cols) && cols[i].ID != col.ID {
+			i++
+		}
+		after := ""
+		switch args[1] {
+		case "left":
+			if i == 0 {
+				return nil
+			}
+			if i >= 2 {
+				after = cols[i-2].Name
+			}
+		case "right":
+			if i+1 >= len(cols) {
+				return nil
+			}
+			after = cols[i+1].Name
+		case "first":
+		default:
+			return usage
+		}
+		t.setMessage(fmt.Sprintf("moving %s", col.Name))
+		if err := t.client.MoveColumn(t.ctx, t.ref, col.Name, after); err != nil {
+			return err
+		}
+	case "delete":
+		del := func() error {
+			t.setMessage(fmt.Sprintf("deleting %s", col.Name))
+			if err := t.client.DeleteColumn(t.ctx, t.ref, col); err != nil {
+				return err
+			}
+			return t.refresh()
+		}
+		// t.res only holds the cards of the current archive mode
+		n, err := t.client.CountColumnCards(t.ctx, t.ref, col)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			t.confirm(fmt.Sprintf("%s has %d card(s), delete it anyway?", col.Name, n), del)
+			return nil
+		}
+		return del()
+	default:
+		return usage
+	}
+	return t.refresh()
+}
+
+// confirm asks a yes or no question in the message area, running fn if the
+// next key pressed is y.
+func (t *tui) confirm(prompt string, fn func() error) {
+	t.message.SetText("[yellow]" + tview.Escape(prompt) + " (y/n)")
+	t.pendingConfirm = fn
+	t.app.SetFocus(t.view())
+}
+
+// noteCommand runs :note add, edit, delete and convert against the column
+// or note that is selected.
+func (t *tui) noteCommand(args []string) error {
+	usage := fmt.Errorf("usage: :note add <text> | edit <text> | delete | convert <owner/repo> [title]")
+	if len(args) == 0 {
+		return usage
+	}
+	row := t.selectedRow()
+	if row < 0 {
+		return fmt.Errorf("no card selected")
+	}
+	sel := t.rows[row]
+	if args[0] == "add" {
+		if len(args) < 2 {
+			return usage
+		}
+		t.setMessage(fmt.Sprintf("adding note to %s", sel.col.Name))
+		if err := t.client.AddNote(t.ctx, t.ref, sel.col.Name, strings.Join(args[1:], " ")); err != nil {
+			return err
+		}
+		return t.refresh()
+	}
+	if sel.card == nil || sel.card.Content.Number != 0 {
+		t.setMessage("select a note first")
+		return nil
+	}
+	note := *sel.card
+	switch args[0] {
+	case "edit":
+		if len(args) < 2 {
+			return usage
+		}
+		t.setMessage("editing note")
+		if err := t.client.EditNote(t.ctx, t.ref, note, strings.Join(args[1:], " ")); err != nil {
+			return err
+		}
+	case "delete":
+		t.setMessage("deleting note")
+		if err := t.client.DeleteCard(t.ctx, t.ref, note); err != nil {
+			return err
+		}
+	case "convert":
+		if len(args) < 2 {
+			return usage
+		}
+		t.setMessage(fmt.Sprintf("converting note to an issue in %s", args[1]))
+		url, err := t.client.ConvertNote(t.ctx, t.ref, note, args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		if err := t.refresh(); err != nil {
+			return err
+		}
+		t.setMessage(fmt.Sprintf("created %s", url))
+		return nil
+	default:
+		return usage
+	}
+	return t.refresh()
+}
+
+// newIssue writes a new issue in the user's editor, filling in the column
+// and repository from the selection, and adds it to the board.
+func (t *tui) newIssue() error {
+	issue := NewIssue{Repo: t.ref.Repo()}
+	if row := t.selectedRow(); row >= 0 {
+		sel := t.rows[row]
+		issue.Column = sel.col.Name
+		if sel.card != nil && sel.card.Content.Repository.NameWithOwner != "" {
+			issue.Repo = sel.card.Content.Repository.NameWithOwner
+		}
+	}
+	var (
+		text string
+		err  error
+	)
+	t.app.Suspend(func() {
+		text, err = editText(issueForm(issue))
 	})
+	if err != nil {
+		return err
+	}
+	issue, err = parseIssueForm(text)
+	if err != nil {
+		return err
+	}
+	if issue.Title == "" {
+		t.setMessage("no title, not creating an issue")
+		return nil
+	}
+	t.setMessage(fmt.Sprintf("creating issue in %s", issue.Repo))
+	created, err := t.client.CreateIssue(t.ctx, t.ref, issue)
+	if err != nil {
+		return err
+	}
+	if err := t.refresh(); err != nil {
+		return err
+	}
+	t.setMessage(fmt.Sprintf("created %s", created.URL))
+	return nil
+}
+
+// currentIssue returns the issue open in the detail pane, or else the one
+// selected, or a zero Content for notes and column headers.
+func (t *tui) currentIssue() Content {
+	if t.resolved != nil && t.resolved.issue != nil {
+		return *t.resolved.issue
+	}
+	var issue Content
+	if t.detail != nil {
+		issue = t.detail.Content
+	} else if row := t.selectedRow(); row >= 0 && t.rows[row].card != nil {
+		issue = t.rows[row].card.Content
+	}
+	if t.resolved != nil {
+		t.resolved.issue = &issue
+	}
+	return issue
+}
+
+// editIssue edits the title and body of the current issue in the user's
+// editor, asking before overwriting changes made on GitHub meanwhile.
+func (t *tui) editIssue() error {
+	current := t.currentIssue()
+	if current.Number == 0 {
+		t.setMessage("select an issue or pull request to edit")
+		return nil
+	}
+	issue, err := t.client.GetIssue(t.ctx, current.Repository.NameWithOwner, current.Number)
+	if err != nil {
+		return err
+	}
+	var text string
+	t.app.Suspend(func() {
+		text, err = editText(editForm(issue))
+	})
+	if err != nil {
+		return err
+	}
+	title, body, err := parseEditForm(text)
+	if err != nil {
+		return err
+	}
+	if title == "" {
+		t.setMessage("no title, not saving")
+		return nil
+	}
+	newTitle, newBody := diffIssue(issue, title, body)
+	if newTitle == nil && newBody == nil {
+		t.setMessage("no changes")
+		return nil
+	}
+	save := func() error {
+		t.setMessage(fmt.Sprintf("saving #%d", issue.Number))
+		if err := t.client.UpdateIssue(t.ctx, issue, newTitle, newBody); err != nil {
+			return err
+		}
+		if err := t.refresh(); err != nil {
+			return err
+		}
+		if err := t.reloadDetail(issue, false); err != nil {
+			return err
+		}
+		t.setMessage(fmt.Sprintf("#%d: changed %s", issue.Number, describeEdit(issue, newTitle, newBody)))
+		return nil
+	}
+	fresh, err := t.client.GetIssue(t.ctx, issue.Repository.NameWithOwner, issue.Number)
+	if err != nil {
+		return err
+	}
+	if editConflict(issue, fresh) {
+		t.confirm(fmt.Sprintf("#%d was edited on GitHub while you were editing, overwrite it?", issue.Number), save)
+		return nil
+	}
+	return save()
+}
 
-	if err := app.SetRoot(vstack, true).EnableMouse(true).Run(); err != nil {
-		panic(err)
+// fail reports err in the message area and the log file. If cmd is not
+// empty it can be run again with :retry.
+func (t *tui) fail(cmd string, err error) {
+	if cmd != "" {
+		t.log.Printf("%s: %v", cmd, err)
+	} else {
+		t.log.Print(err)
+	}
+	t.lastFailed = cmd
+	msg := tview.Escape(err.Error())
+	if cmd != "" {
+		msg += " [gray](:retry to try again)"
 	}
+	t.message.SetText("[red]" + msg)
 }
 
-func refreshTable(ctx context.Context, table *tview.Table, id int) (map[string]Content, error) {
-	res, err := GetProject(ctx, id)
+// openLog opens the file TUI errors are logged to, $PROJ_LOG or proj.log in
+// the user cache directory.
+func openLog() *log.Logger {
+	path := os.Getenv("PROJ_LOG")
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return log.New(ioutil.Discard, "", 0)
+		}
+		path = filepath.Join(dir, "proj", "proj.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return log.New(ioutil.Discard, "", 0)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
-		return nil, err
+		return log.New(ioutil.Discard, "", 0)
+	}
+	return log.New(f, "", log.LstdFlags)
+}
+
+func (t *tui) setMessage(msg string) {
+	t.message.SetText(tview.Escape(msg))
+}
+
+// setFilter applies the filter as it's typed, keeping the last valid one
+// while the text doesn't parse.
+func (t *tui) setFilter(text string) {
+	f, err := ParseFilter(text)
+	if err != nil {
+		t.message.SetText("[gray]" + tview.Escape(err.Error()))
+		return
+	}
+	t.message.SetText("")
+	t.filter = f
+	t.render()
+}
+
+func (t *tui) refresh() error {
+	res, err := t.client.GetProject(t.ctx, t.ref)
+	if err != nil {
+		return err
+	}
+	t.res = res
+	t.render()
+	return nil
+}
+
+// render draws the cards from the last refresh that match the filter.
+func (t *tui) render() {
+	if t.res == nil {
+		return
 	}
+	res := t.res
+	if !t.filter.Empty() {
+		res = filterCards(res, t.filter.Match)
+	}
+
+	table := t.table
 	table.Clear()
-	var (
-		n      = -1
-		issues = make(map[string]Content)
-	)
-	for _, col := range res.Organization.Project.Columns.Nodes {
+	t.rows = t.rows[:0]
+	n := -1
+	projectName := res.Owner.Project.Name
+	if res.Truncated {
+		projectName += " (truncated)"
+	}
+	for _, col := range res.Owner.Project.Columns.Nodes {
+		if len(col.Cards.Nodes) == 0 && !t.filter.Empty() {
+			continue
+		}
 		n++
+		t.rows = append(t.rows, tuiRow{col: col})
 		name := col.Name
 		table.SetCell(n, 1, tview.NewTableCell(name).SetTextColor(tcell.ColorGreen))
-		table.SetCell(n, 2, tview.NewTableCell(res.Organization.Project.Name).SetTextColor(tcell.ColorGreen))
-		for _, card := range col.Cards.Nodes {
+		table.SetCell(n, 2, tview.NewTableCell(projectName).SetTextColor(tcell.ColorGreen))
+		for i := range col.Cards.Nodes {
+			card := col.Cards.Nodes[i]
 			n++
+			t.rows = append(t.rows, tuiRow{col: col, card: &col.Cards.Nodes[i]})
 			if card.Content.Number == 0 {
 				table.SetCell(
 					n, 0,
@@ -233,7 +1151,7 @@ func refreshTable(ctx context.Context, table *tview.Table, id int) (map[string]C
 				)
 				table.SetCell(
 					n, 2,
-					tview.NewTableCell(capStr(card.Note, 60)),
+					tview.NewTableCell(capStr(oneLine(card.Note), 60)),
 				)
 				continue
 			}
@@ -242,8 +1160,6 @@ func refreshTable(ctx context.Context, table *tview.Table, id int) (map[string]C
 			title := capStr(card.Content.Title, 60)
 			url := card.Content.URL
 
-			issues[number] = card.Content
-
 			table.SetCell(
 				n, 0,
 				tview.NewTableCell(number).SetTextColor(tcell.ColorBlue),
@@ -260,10 +1176,43 @@ func refreshTable(ctx context.Context, table *tview.Table, id int) (map[string]C
 				n, 3,
 				tview.NewTableCell(url).SetTextColor(tcell.ColorLavender),
 			)
+			for i, f := range t.fields {
+				table.SetCell(n, 4+i, fieldCell(card.Content, f))
+			}
 		}
 	}
+	// commands only act on the marked cards that are shown, so unmark the
+	// ones the filter hides rather than count them
+	shown := make(map[string]bool)
+	for _, row := range t.rows {
+		if row.card != nil {
+			shown[row.card.ID] = true
+		}
+	}
+	for id := range t.marked {
+		if !shown[id] {
+			delete(t.marked, id)
+		}
+	}
+	if t.kanban {
+		t.renderBoard(res)
+	}
+	t.paintMarks()
+}
 
-	return issues, nil
+// fieldCell renders an optional column, with labels in their own colors.
+func fieldCell(c Content, field string) *tview.TableCell {
+	if field != "labels" {
+		return tview.NewTableCell(tview.Escape(fieldText(c, field))).SetTextColor(tcell.ColorGray)
+	}
+	labels := make([]string, len(c.Labels.Nodes))
+	for i, l := range c.Labels.Nodes {
+		labels[i] = tview.Escape(l.Name)
+		if len(l.Color) == 6 {
+			labels[i] = "[#" + l.Color + "]" + labels[i] + "[-]"
+		}
+	}
+	return tview.NewTableCell(strings.Join(labels, ","))
 }
 
 func getURL(table *tview.Table, row int) string {